@@ -0,0 +1,220 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package calcitesql
+
+import "strings"
+
+// Statement is the client-side classification of a SQL statement
+type Statement struct {
+	// Keyword is the leading keyword in upper case, e.g. SELECT or UPSERT
+	Keyword string
+	// ReadOnly reports whether the statement is known not to modify data
+	ReadOnly bool
+	// Trigger is the keyword that makes the statement a write, empty for read-only statements
+	Trigger string
+	// Destructive describes why the statement can destroy data in bulk, e.g.
+	// "DELETE without WHERE", or is empty for ordinary statements
+	Destructive string
+	// Table is the object affected by a destructive statement, if it could be determined
+	Table string
+
+	tokens []Token
+}
+
+// Statements that only read data, provided no write keyword appears in them
+var readOnlyKeywords = map[string]bool{
+	"SELECT":   true,
+	"VALUES":   true,
+	"WITH":     true,
+	"SHOW":     true,
+	"DESCRIBE": true,
+	"DESC":     true,
+}
+
+// Keywords that start a statement modifying data or schema
+var writeKeywords = map[string]bool{
+	"INSERT":   true,
+	"UPSERT":   true,
+	"UPDATE":   true,
+	"DELETE":   true,
+	"MERGE":    true,
+	"DROP":     true,
+	"ALTER":    true,
+	"CREATE":   true,
+	"TRUNCATE": true,
+	"GRANT":    true,
+	"REVOKE":   true,
+}
+
+// Classify determines whether query could modify data. Anything that is not
+// recognisably a query is treated as a write, so unknown statements are
+// blocked rather than let through.
+func Classify(query string) Statement {
+	st := Statement{tokens: SignificantTokens(query)}
+
+	for _, tok := range st.tokens {
+		if tok.Kind == TokenWord {
+			st.Keyword = tok.Upper()
+			break
+		}
+		if tok.Text != "(" {
+			break
+		}
+	}
+
+	switch {
+	case st.Keyword == "":
+		// Nothing to run
+		st.ReadOnly = len(st.tokens) == 0
+		if !st.ReadOnly {
+			st.Trigger = st.tokens[0].Text
+		}
+	case st.Keyword == "EXPLAIN":
+		// The explained statement is planned but never executed
+		st.ReadOnly = true
+	case readOnlyKeywords[st.Keyword]:
+		st.ReadOnly = true
+		for i, tok := range st.tokens {
+			if tok.Kind != TokenWord || !writeKeywords[tok.Upper()] {
+				continue
+			}
+			// SELECT ... FOR UPDATE only takes locks
+			if tok.IsWord("UPDATE") && i > 0 && st.tokens[i-1].IsWord("FOR") {
+				continue
+			}
+			st.ReadOnly = false
+			st.Trigger = tok.Upper()
+			break
+		}
+	default:
+		st.Trigger = st.Keyword
+		st.classifyDestructive()
+	}
+	return st
+}
+
+// classifyDestructive recognises the statements that warrant a confirmation
+// before they run: DROP, DELETE or UPDATE without WHERE, UPSERT ... SELECT
+// and ALTER TABLE ... DROP COLUMN.
+func (st *Statement) classifyDestructive() {
+	words := st.tokens
+	// Skip opening parentheses so words[0] is the leading keyword
+	for len(words) > 0 && words[0].Text == "(" {
+		words = words[1:]
+	}
+
+	switch st.Keyword {
+	case "DROP":
+		if len(words) < 2 {
+			st.Destructive = "DROP"
+			return
+		}
+		object := words[1].Upper()
+		st.Destructive = "DROP " + object
+		st.Table, _ = objectName(words[2:])
+	case "DELETE":
+		if st.hasTopLevelWord("WHERE") {
+			return
+		}
+		st.Destructive = "DELETE without WHERE"
+		if len(words) > 1 && words[1].IsWord("FROM") {
+			st.Table, _ = objectName(words[2:])
+		}
+	case "UPDATE":
+		if st.hasTopLevelWord("WHERE") {
+			return
+		}
+		st.Destructive = "UPDATE without WHERE"
+		st.Table, _ = objectName(words[1:])
+	case "UPSERT":
+		if !st.hasWord("SELECT") {
+			return
+		}
+		st.Destructive = "UPSERT ... SELECT"
+		if len(words) > 1 && words[1].IsWord("INTO") {
+			st.Table, _ = objectName(words[2:])
+		}
+	case "ALTER":
+		if len(words) < 3 || !words[1].IsWord("TABLE") {
+			return
+		}
+		table, rest := objectName(words[2:])
+		for i := 0; i+1 < len(rest); i++ {
+			if rest[i].IsWord("DROP") && rest[i+1].IsWord("COLUMN") {
+				st.Destructive = "ALTER TABLE ... DROP COLUMN"
+				st.Table = table
+				return
+			}
+		}
+	}
+}
+
+func (st *Statement) hasWord(w string) bool {
+	for _, tok := range st.tokens {
+		if tok.IsWord(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTopLevelWord reports whether w appears in the statement outside
+// parentheses, so that the WHERE of a subquery does not count. Parentheses
+// around the whole statement are not counted either.
+func (st *Statement) hasTopLevelWord(w string) bool {
+	depth := 0
+	leading := true
+	for _, tok := range st.tokens {
+		if tok.Kind == TokenPunct && tok.Text == "(" {
+			if !leading {
+				depth++
+			}
+			continue
+		}
+		leading = false
+		switch {
+		case tok.Kind == TokenPunct && tok.Text == ")":
+			depth--
+		case depth == 0 && tok.IsWord(w):
+			return true
+		}
+	}
+	return false
+}
+
+// objectName reads a possibly qualified name such as SALES."Orders", skipping
+// a leading IF [NOT] EXISTS, and returns it with the remaining tokens.
+func objectName(tokens []Token) (string, []Token) {
+	for len(tokens) > 0 && (tokens[0].IsWord("IF") || tokens[0].IsWord("NOT") || tokens[0].IsWord("EXISTS")) {
+		tokens = tokens[1:]
+	}
+	var name strings.Builder
+	for len(tokens) > 0 {
+		if tokens[0].Kind != TokenWord && tokens[0].Kind != TokenQuotedIdent {
+			break
+		}
+		name.WriteString(tokens[0].Text)
+		tokens = tokens[1:]
+		if len(tokens) == 0 || tokens[0].Text != "." {
+			break
+		}
+		name.WriteString(".")
+		tokens = tokens[1:]
+	}
+	return name.String(), tokens
+}
@@ -0,0 +1,70 @@
+package calcitesql
+
+import (
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		query    string
+		keyword  string
+		readOnly bool
+		trigger  string
+	}{
+		{query: "SELECT * FROM t", keyword: "SELECT", readOnly: true},
+		{query: "  -- comment\nselect 1", keyword: "SELECT", readOnly: true},
+		{query: "(SELECT 1) UNION (SELECT 2)", keyword: "SELECT", readOnly: true},
+		{query: "VALUES (1, 2)", keyword: "VALUES", readOnly: true},
+		{query: "EXPLAIN PLAN FOR DELETE FROM t", keyword: "EXPLAIN", readOnly: true},
+		{query: "WITH x AS (SELECT 1) SELECT * FROM x", keyword: "WITH", readOnly: true},
+		{query: "SELECT * FROM t FOR UPDATE", keyword: "SELECT", readOnly: true},
+		{query: "SELECT 'DELETE' FROM t", keyword: "SELECT", readOnly: true},
+		{query: "", readOnly: true},
+		{query: "WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x", keyword: "WITH", trigger: "INSERT"},
+		{query: "upsert into t values (1)", keyword: "UPSERT", trigger: "UPSERT"},
+		{query: "DELETE FROM t", keyword: "DELETE", trigger: "DELETE"},
+		{query: "DROP TABLE t", keyword: "DROP", trigger: "DROP"},
+		{query: "SET x = 1", keyword: "SET", trigger: "SET"},
+	}
+
+	for _, tt := range tests {
+		got := Classify(tt.query)
+		if got.Keyword != tt.keyword || got.ReadOnly != tt.readOnly || got.Trigger != tt.trigger {
+			t.Errorf("Classify(%q) = {%q %v %q}, want {%q %v %q}",
+				tt.query, got.Keyword, got.ReadOnly, got.Trigger, tt.keyword, tt.readOnly, tt.trigger)
+		}
+	}
+}
+
+func TestClassifyDestructive(t *testing.T) {
+	tests := []struct {
+		query       string
+		destructive string
+		table       string
+	}{
+		{query: "DROP TABLE IF EXISTS sales.\"Orders\"", destructive: "DROP TABLE", table: "sales.\"Orders\""},
+		{query: "drop view v1", destructive: "DROP VIEW", table: "v1"},
+		{query: "DELETE FROM ORDERS", destructive: "DELETE without WHERE", table: "ORDERS"},
+		{query: "DELETE FROM ORDERS WHERE ID = 1", destructive: ""},
+		{query: "(DELETE FROM ORDERS WHERE ID = 1)", destructive: ""},
+		{query: "DELETE FROM ORDERS WHERE ID IN (SELECT ID FROM T WHERE X = 1)", destructive: ""},
+		{query: "DELETE FROM ORDERS O WHERE EXISTS (SELECT 1)", destructive: ""},
+		{query: "DELETE FROM (SELECT * FROM ORDERS WHERE ID = 1)", destructive: "DELETE without WHERE"},
+		{query: "UPDATE ORDERS SET QTY = 0", destructive: "UPDATE without WHERE", table: "ORDERS"},
+		{query: "UPDATE ORDERS SET QTY = (SELECT MAX(QTY) FROM T WHERE ID = 1)", destructive: "UPDATE without WHERE", table: "ORDERS"},
+		{query: "UPDATE ORDERS SET QTY = 0 WHERE ID = 1", destructive: ""},
+		{query: "SELECT * FROM T WHERE X IN (SELECT 1)", destructive: ""},
+		{query: "UPSERT INTO T2 (ID) SELECT ID FROM T1", destructive: "UPSERT ... SELECT", table: "T2"},
+		{query: "UPSERT INTO T2 VALUES (1)", destructive: ""},
+		{query: "ALTER TABLE T DROP COLUMN C", destructive: "ALTER TABLE ... DROP COLUMN", table: "T"},
+		{query: "ALTER TABLE T ADD C VARCHAR", destructive: ""},
+		{query: "SELECT * FROM T", destructive: ""},
+	}
+
+	for _, tt := range tests {
+		got := Classify(tt.query)
+		if got.Destructive != tt.destructive || got.Table != tt.table {
+			t.Errorf("Classify(%q) = {%q %q}, want {%q %q}", tt.query, got.Destructive, got.Table, tt.destructive, tt.table)
+		}
+	}
+}
@@ -18,41 +18,131 @@
 package calcitesql
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
 	_ "github.com/apache/calcite-avatica-go/v5"
-	"github.com/olekukonko/tablewriter"
 )
 
-func ExecuteQuery(db *sql.DB, query string) {
+// Queryer is the subset of *sql.DB, *sql.Conn and *sql.Tx used to run statements,
+// so queries can be executed inside an open transaction.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Options controls how query results are fetched and displayed
+type Options struct {
+	// MaxRows stops fetching after this many rows; 0 fetches the whole result
+	MaxRows int
+	// Format is one of Formats; empty means FormatTable
+	Format string
+	// Stream prints table rows as they are fetched instead of after the
+	// whole result has been read
+	Stream bool
+	// Expanded selects the record-per-block layout for FormatTable: one of
+	// ExpandedModes, empty means ExpandedOff
+	Expanded string
+	// TermWidth is the terminal width used by ExpandedAuto and to fit tables
+	// without a MaxColWidth; 0 if unknown
+	TermWidth int
+	// MaxColWidth limits the width of every table column; 0 narrows the
+	// widest columns only as far as needed to fit TermWidth
+	MaxColWidth int
+	// ColumnWidths overrides MaxColWidth for single columns, keyed by column
+	// name as the server reports it
+	ColumnWidths map[string]int
+	// Overflow is one of Overflows and decides what happens to values wider
+	// than their column; empty means OverflowTruncate
+	Overflow string
+	// TimeZone is the zone timestamps are displayed in; nil keeps the zone
+	// returned by the driver
+	TimeZone *time.Location
+	// BinaryFormat is one of BinaryFormats; empty means BinaryHex
+	BinaryFormat string
+	// NullString is printed for NULL in table and expanded output; nil
+	// means "NULL", while an empty string leaves the cell empty. CSV leaves
+	// NULL fields empty and JSON uses null.
+	NullString *string
+	// ColorNull dims NULL in table and expanded output so it stands apart
+	// from a string that reads the same
+	ColorNull bool
+	// NullStyle is the ANSI SGR parameter list, such as "2" or "1;31", NULL
+	// is drawn with when ColorNull is set; empty means dimmed
+	NullStyle string
+	// HeaderStyle is the ANSI SGR parameter list column names are drawn with
+	// in table and expanded output; empty leaves them plain
+	HeaderStyle string
+	// FloatPrecision is the number of decimals printed for FLOAT, REAL and
+	// DOUBLE values; 0 prints the shortest exact representation
+	FloatPrecision int
+	// Out receives the result; nil means stdout
+	Out io.Writer
+	// Status receives the row count and timing footer; nil means Out, or
+	// stderr for CSV and JSON so the footer does not mix with the data
+	Status io.Writer
+	// Timing adds the time spent running the query and fetching its rows
+	// to the footer
+	Timing bool
+	// Quiet leaves out the footer
+	Quiet bool
+	// NoHeader leaves out the CSV header line
+	NoHeader bool
+}
+
+// ErrOutputClosed is returned by an Options.Out writer that no longer accepts
+// output, for example a pager the user has quit. ExecuteQuery then stops
+// fetching rows without reporting an error.
+var ErrOutputClosed = errors.New("output closed")
+
+// scanRow reads the current row of rows into dest. Scanning into interface{}
+// values only fails in ways tests cannot arrange, so they replace it.
+var scanRow = func(rows *sql.Rows, dest []interface{}) error {
+	return rows.Scan(dest...)
+}
+
+// ExecuteQuery runs query and prints its result in the format chosen by opts.
+// Errors are reported to stderr; the error from running the query is also
+// returned so callers can react to a lost connection.
+func ExecuteQuery(db Queryer, query string, opts Options) error {
 	cmd := strings.TrimRight(query, ";")
 	start := time.Now()
 	// Execute the query
-	rows, err := db.Query(cmd)
-	duration := time.Since(start)
+	rows, err := db.QueryContext(context.Background(), cmd)
+	stats := queryStats{query: time.Since(start)}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error executing query:", err)
-		return
+		return err
 	}
 	defer rows.Close()
 
-	// Get column names
-	columns, err := rows.Columns()
+	// Get column names and types
+	columns, err := resultColumns(rows)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error retrieving column names:", err)
-		return
+		return err
 	}
 
-	// Create a new table writer for each query
-	table := tablewriter.NewWriter(os.Stdout)
-	// TODO: set table properties to new version
-	// table.SetAutoFormatHeaders(true)
-	// table.SetAutoWrapText(false)
-	// table.SetReflowDuringAutoWrap(true)
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	// Create a new formatter for each query
+	formatter, err := NewFormatter(out, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil
+	}
+	if err := formatter.Header(columns); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing results:", err)
+		return nil
+	}
 
 	// Create a slice to store the query results
 	values := make([]interface{}, len(columns))
@@ -63,33 +153,105 @@ func ExecuteQuery(db *sql.DB, query string) {
 
 	// Fetch and print rows
 	count := 0
-	for rows.Next() {
-		err = rows.Scan(scanArgs...)
+	truncated := false
+	var fetchErr error
+	for {
+		// Only the time spent in the driver counts as fetching, not the
+		// time taken to print the rows
+		fetchStart := time.Now()
+		if !rows.Next() {
+			stats.fetch += time.Since(fetchStart)
+			// Next also stops when fetching fails, such as when the
+			// connection is lost partway through the result
+			fetchErr = rows.Err()
+			break
+		}
+		// Stop at the row cap without buffering the rest of the result;
+		// closing rows releases the server-side statement
+		if opts.MaxRows > 0 && count == opts.MaxRows {
+			truncated = true
+			break
+		}
+		err = scanRow(rows, scanArgs)
+		stats.fetch += time.Since(fetchStart)
+		if count == 0 {
+			stats.firstRow = time.Since(start)
+		}
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "Error retrieving row data:", err)
-			continue
+			fetchErr = err
+			break
 		}
 
-		// Prepare row data
-		rowData := make([]string, len(columns))
-		for i, v := range values {
-			if v != nil {
-				rowData[i] = fmt.Sprintf("%v", v)
-			} else {
-				rowData[i] = "NULL"
+		if err := formatter.Row(values); err != nil {
+			if !errors.Is(err, ErrOutputClosed) {
+				fmt.Fprintln(os.Stderr, "Error writing results:", err)
 			}
+			return nil
 		}
-
-		// Add row to the table
-		table.Append(rowData)
 		count++
 	}
 
-	// Set the table headers
-	table.Header(columns)
+	// Finish the output
+	if err := formatter.Close(); err != nil {
+		if !errors.Is(err, ErrOutputClosed) {
+			fmt.Fprintln(os.Stderr, "Error writing results:", err)
+		}
+		return nil
+	}
+
+	// The rows fetched before a failure are shown, but not a footer that
+	// would report the result as complete. A row that cannot be scanned
+	// ends the result the same way.
+	if fetchErr != nil {
+		fmt.Fprintln(os.Stderr, "Error retrieving row data:", fetchErr)
+		return fetchErr
+	}
 
-	// Render the table
-	table.Render()
+	stats.rows = count
+	stats.total = time.Since(start)
 
-	fmt.Printf("Rows: %d\nExecution Time: %s\n\n", count, duration)
+	status := opts.Status
+	switch {
+	case status != nil:
+	case opts.Format == FormatCSV || opts.Format == FormatJSON:
+		status = os.Stderr
+	default:
+		status = out
+	}
+	if truncated {
+		fmt.Fprintf(status, "showing first %d rows (more available)\n", count)
+	}
+	if !opts.Quiet {
+		stats.write(status, opts.Timing)
+	}
+	return nil
+}
+
+// queryStats holds the row count and timings reported after a query
+type queryStats struct {
+	rows int
+	// query is the time until the server accepted the query
+	query time.Duration
+	// firstRow is the time from sending the query until the first row arrived
+	firstRow time.Duration
+	// fetch is the time spent fetching rows from the driver
+	fetch time.Duration
+	// total is the wall time including printing the result
+	total time.Duration
+}
+
+func (st queryStats) write(w io.Writer, timing bool) {
+	fmt.Fprintf(w, "Rows: %d\n", st.rows)
+	if timing {
+		fmt.Fprintf(w, "Execution Time: %s\n", st.query)
+		if st.rows > 0 {
+			fmt.Fprintf(w, "First Row: %s\n", st.firstRow)
+		}
+		fmt.Fprintf(w, "Fetch Time: %s\n", st.fetch)
+		fmt.Fprintf(w, "Total Time: %s\n", st.total)
+		if st.rows > 0 && st.fetch > 0 {
+			fmt.Fprintf(w, "Rows/sec: %.1f\n", float64(st.rows)/st.fetch.Seconds())
+		}
+	}
+	fmt.Fprintln(w)
 }
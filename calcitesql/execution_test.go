@@ -1,6 +1,11 @@
 package calcitesql
 
 import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -17,6 +22,7 @@ func TestExecuteQuery(t *testing.T) {
 	tests := []struct {
 		name  string
 		query string
+		opts  Options
 		mock  func()
 	}{
 		{
@@ -37,15 +43,142 @@ func TestExecuteQuery(t *testing.T) {
 				mock.ExpectQuery("SELECT \\* FROM empty").WillReturnRows(rows)
 			},
 		},
+		{
+			name:  "row cap",
+			query: "SELECT * FROM big",
+			opts:  Options{MaxRows: 2},
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"id"}).
+					AddRow(1).
+					AddRow(2).
+					AddRow(3)
+				mock.ExpectQuery("SELECT \\* FROM big").WillReturnRows(rows).RowsWillBeClosed()
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			ExecuteQuery(db, tt.query)
+			ExecuteQuery(db, tt.query, tt.opts)
 			if err := mock.ExpectationsWereMet(); err != nil {
 				t.Errorf("There were unfulfilled expectations: %s", err)
 			}
 		})
 	}
 }
+
+func TestExecuteQueryFooter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		name       string
+		opts       Options
+		wantStatus []string
+		notStatus  []string
+	}{
+		{
+			name:       "rows only",
+			opts:       Options{Format: FormatCSV},
+			wantStatus: []string{"Rows: 2\n"},
+			notStatus:  []string{"Fetch Time"},
+		},
+		{
+			name:       "timing",
+			opts:       Options{Timing: true},
+			wantStatus: []string{"Rows: 2\n", "Execution Time: ", "First Row: ", "Fetch Time: ", "Total Time: ", "Rows/sec: "},
+		},
+		{
+			name:      "quiet",
+			opts:      Options{Timing: true, Quiet: true},
+			notStatus: []string{"Rows:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, status bytes.Buffer
+			tt.opts.Out = &out
+			tt.opts.Status = &status
+			mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+			ExecuteQuery(db, "SELECT id FROM t", tt.opts)
+
+			if strings.Contains(out.String(), "Rows:") {
+				t.Errorf("Expected the footer to stay out of the result, got %q", out.String())
+			}
+			for _, want := range tt.wantStatus {
+				if !strings.Contains(status.String(), want) {
+					t.Errorf("Expected footer to contain %q, got %q", want, status.String())
+				}
+			}
+			for _, unwanted := range tt.notStatus {
+				if strings.Contains(status.String(), unwanted) {
+					t.Errorf("Expected footer without %q, got %q", unwanted, status.String())
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteQueryFetchError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var out, status bytes.Buffer
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3).RowError(1, driver.ErrBadConn)
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+	err = ExecuteQuery(db, "SELECT id FROM t", Options{Format: FormatCSV, Out: &out, Status: &status})
+
+	if !errors.Is(err, driver.ErrBadConn) {
+		t.Errorf("Expected the fetch error to be returned, got %v", err)
+	}
+	if want := "id\n1\n"; out.String() != want {
+		t.Errorf("Expected the rows before the error %q, got %q", want, out.String())
+	}
+	if strings.Contains(status.String(), "Rows:") {
+		t.Errorf("Expected no footer for an incomplete result, got %q", status.String())
+	}
+}
+
+func TestExecuteQueryScanError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// The second row cannot be scanned
+	scanned := 0
+	errScan := errors.New("unsupported value")
+	defer func(scan func(*sql.Rows, []interface{}) error) { scanRow = scan }(scanRow)
+	scanRow = func(rows *sql.Rows, dest []interface{}) error {
+		if scanned++; scanned == 2 {
+			return errScan
+		}
+		return rows.Scan(dest...)
+	}
+
+	var out, status bytes.Buffer
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3)).RowsWillBeClosed()
+	err = ExecuteQuery(db, "SELECT id FROM t", Options{Format: FormatCSV, Out: &out, Status: &status})
+
+	if !errors.Is(err, errScan) {
+		t.Errorf("Expected the scan error to be returned, got %v", err)
+	}
+	if want := "id\n1\n"; out.String() != want {
+		t.Errorf("Expected the rows before the bad row %q, got %q", want, out.String())
+	}
+	if status.Len() != 0 {
+		t.Errorf("Expected no footer for an incomplete result, got %q", status.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,484 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package calcitesql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/pkg/twwidth"
+	"github.com/olekukonko/tablewriter/tw"
+)
+
+// Output formats accepted in Options.Format
+const (
+	FormatTable = "table"
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatTable, FormatCSV, FormatJSON}
+
+// Expanded display modes accepted in Options.Expanded
+const (
+	ExpandedOff  = "off"
+	ExpandedOn   = "on"
+	ExpandedAuto = "auto"
+)
+
+// ExpandedModes lists the supported expanded display modes
+var ExpandedModes = []string{ExpandedOff, ExpandedOn, ExpandedAuto}
+
+// streamSampleRows is the number of rows used to size the columns of a
+// streamed table before anything is printed
+const streamSampleRows = 100
+
+// Formatter renders a result set one row at a time
+type Formatter interface {
+	// Header is called once with the result columns before any row
+	Header(columns []Column) error
+	// Row is called for every fetched row; nil values are SQL NULLs. The
+	// slice is reused between calls.
+	Row(values []interface{}) error
+	// Close finishes the output once all rows have been passed to Row
+	Close() error
+}
+
+// NewFormatter returns the formatter for opts.Format writing to w
+func NewFormatter(w io.Writer, opts Options) (Formatter, error) {
+	switch opts.Format {
+	case "", FormatTable:
+		if opts.Expanded == ExpandedOn {
+			return &expandedFormatter{w: w, opts: opts}, nil
+		}
+		return &tableFormatter{
+			w:          w,
+			opts:       opts,
+			stream:     opts.Stream,
+			autoExpand: opts.Expanded == ExpandedAuto,
+			termWidth:  opts.TermWidth,
+		}, nil
+	case FormatCSV:
+		return &csvFormatter{w: w, opts: opts}, nil
+	case FormatJSON:
+		return &jsonFormatter{w: w, opts: opts}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", opts.Format)
+}
+
+// ANSI sequences used to dim NULL
+const (
+	ansiDim   = "\x1b[2m"
+	ansiReset = "\x1b[0m"
+)
+
+// styled wraps text in the SGR sequence for style
+func styled(text, style string) string {
+	return "\x1b[" + style + "m" + text + ansiReset
+}
+
+// headerNames returns the column names for a table header. Styled names
+// are put in title form here, since tablewriter's own formatting would
+// upper-case the escape sequences.
+func (o Options) headerNames(columns []Column) []string {
+	names := columnNames(columns)
+	if o.HeaderStyle == "" {
+		return names
+	}
+	for i, name := range names {
+		names[i] = styled(tw.Title(name), o.HeaderStyle)
+	}
+	return names
+}
+
+// headerAutoFormat tells tablewriter whether to format the header itself
+func (o Options) headerAutoFormat() tw.State {
+	if o.HeaderStyle == "" {
+		return tw.On
+	}
+	return tw.Off
+}
+
+// rowStrings renders a row for the table and expanded layouts
+func (o Options) rowStrings(columns []Column, values []interface{}) []string {
+	cells := make([]string, len(values))
+	for i, v := range values {
+		var c Column
+		if i < len(columns) {
+			c = columns[i]
+		}
+		cells[i] = o.formatValue(c, v)
+		if v == nil && o.ColorNull {
+			if o.NullStyle != "" {
+				cells[i] = styled(cells[i], o.NullStyle)
+			} else {
+				cells[i] = ansiDim + cells[i] + ansiReset
+			}
+		}
+	}
+	return cells
+}
+
+// rowAlignment right-aligns numeric columns so digits line up
+func rowAlignment(columns []Column) tw.CellAlignment {
+	align := tw.CellAlignment{Global: tw.AlignLeft, PerColumn: make([]tw.Align, len(columns))}
+	for i, c := range columns {
+		align.PerColumn[i] = tw.AlignLeft
+		if c.IsNumeric() {
+			align.PerColumn[i] = tw.AlignRight
+		}
+	}
+	return align
+}
+
+// tableFormatter draws an ASCII table. By default all rows are buffered so
+// the columns fit the widest value; in streaming mode the columns are sized
+// from the first rows and everything after is printed as it arrives. With
+// autoExpand it switches to the expanded layout when the table would be wider
+// than the terminal.
+type tableFormatter struct {
+	w          io.Writer
+	opts       Options
+	stream     bool
+	autoExpand bool
+	termWidth  int
+	columns    []Column
+	widths     []int
+	// limits holds the maximum width of each column once the layout is
+	// fixed; 0 means unlimited
+	limits []int
+	// rows holds the whole result, or the sample in streaming mode
+	rows [][]string
+	// table or expanded is set once a streamed result has started printing
+	table    *tablewriter.Table
+	expanded *expandedFormatter
+}
+
+func (f *tableFormatter) Header(columns []Column) error {
+	f.columns = columns
+	f.widths = make([]int, len(columns))
+	for i, c := range columns {
+		f.widths[i] = twwidth.Width(c.Name)
+	}
+	return nil
+}
+
+func (f *tableFormatter) Row(values []interface{}) error {
+	cells := f.opts.rowStrings(f.columns, values)
+	switch {
+	case f.expanded != nil:
+		return f.expanded.writeRecord(cells)
+	case f.table != nil:
+		return f.table.Append(f.fitRow(cells))
+	}
+
+	for i, cell := range cells {
+		if i < len(f.widths) && twwidth.Width(cell) > f.widths[i] {
+			f.widths[i] = twwidth.Width(cell)
+		}
+	}
+	f.rows = append(f.rows, cells)
+	if f.stream && len(f.rows) == streamSampleRows {
+		return f.startStream()
+	}
+	return nil
+}
+
+func (f *tableFormatter) Close() error {
+	if f.stream && f.table == nil && f.expanded == nil {
+		if err := f.startStream(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case f.expanded != nil:
+		return nil
+	case f.table != nil:
+		return f.table.Close()
+	}
+
+	if f.tooWide() {
+		return f.writeExpanded()
+	}
+	f.layout()
+	table := tablewriter.NewTable(f.w,
+		tablewriter.WithRowAlignmentConfig(rowAlignment(f.columns)),
+		tablewriter.WithHeaderAutoFormat(f.opts.headerAutoFormat()),
+	)
+	table.Header(f.opts.headerNames(f.columns))
+	for _, row := range f.rows {
+		if err := table.Append(f.fitRow(row)); err != nil {
+			return err
+		}
+	}
+	return table.Render()
+}
+
+// tooWide reports whether the table would not fit on the terminal
+func (f *tableFormatter) tooWide() bool {
+	if !f.autoExpand || f.termWidth <= 0 {
+		return false
+	}
+	width := borderWidth(len(f.widths))
+	for _, w := range f.widths {
+		width += w
+	}
+	return width > f.termWidth
+}
+
+// writeExpanded prints the rows collected so far as expanded records
+func (f *tableFormatter) writeExpanded() error {
+	f.expanded = newExpandedFormatter(f.w, f.opts, f.columns)
+	for _, row := range f.rows {
+		if err := f.expanded.writeRecord(row); err != nil {
+			return err
+		}
+	}
+	f.rows = nil
+	return nil
+}
+
+// startStream fixes the column widths from the sampled rows and prints them
+func (f *tableFormatter) startStream() error {
+	if f.tooWide() {
+		return f.writeExpanded()
+	}
+
+	f.layout()
+	widths := tw.NewMapper[int, int]()
+	for i, w := range f.widths {
+		if f.limits[i] > 0 {
+			w = min(w, f.limits[i])
+		}
+		// Leave room for the cell padding
+		widths.Set(i, w+2)
+	}
+
+	f.table = tablewriter.NewTable(f.w,
+		tablewriter.WithStreaming(tw.StreamConfig{Enable: true}),
+		tablewriter.WithColumnWidths(widths),
+		tablewriter.WithRowAutoWrap(tw.WrapBreak),
+		tablewriter.WithRowAlignmentConfig(rowAlignment(f.columns)),
+		tablewriter.WithHeaderAutoFormat(f.opts.headerAutoFormat()),
+	)
+	if err := f.table.Start(); err != nil {
+		return err
+	}
+	f.table.Header(f.opts.headerNames(f.columns))
+	for _, row := range f.rows {
+		if err := f.table.Append(f.fitRow(row)); err != nil {
+			return err
+		}
+	}
+	f.rows = nil
+	return nil
+}
+
+// expandedFormatter prints every row as a block of "column | value" lines,
+// which keeps results with many columns readable
+type expandedFormatter struct {
+	w         io.Writer
+	opts      Options
+	columns   []Column
+	nameWidth int
+	records   int
+}
+
+func newExpandedFormatter(w io.Writer, opts Options, columns []Column) *expandedFormatter {
+	f := &expandedFormatter{w: w, opts: opts}
+	f.Header(columns)
+	return f
+}
+
+func (f *expandedFormatter) Header(columns []Column) error {
+	f.columns = columns
+	for _, c := range columns {
+		if twwidth.Width(c.Name) > f.nameWidth {
+			f.nameWidth = twwidth.Width(c.Name)
+		}
+	}
+	return nil
+}
+
+func (f *expandedFormatter) Row(values []interface{}) error {
+	return f.writeRecord(f.opts.rowStrings(f.columns, values))
+}
+
+func (f *expandedFormatter) Close() error {
+	if f.records == 0 {
+		_, err := io.WriteString(f.w, "(0 rows)\n")
+		return err
+	}
+	return nil
+}
+
+func (f *expandedFormatter) writeRecord(cells []string) error {
+	f.records++
+
+	valueWidth := 0
+	for _, cell := range cells {
+		for _, line := range strings.Split(cell, "\n") {
+			if twwidth.Width(line) > valueWidth {
+				valueWidth = twwidth.Width(line)
+			}
+		}
+	}
+
+	var b strings.Builder
+	title := fmt.Sprintf("-[ RECORD %d ]", f.records)
+	b.WriteString(title)
+	b.WriteString(strings.Repeat("-", max(f.nameWidth+1-twwidth.Width(title), 0)))
+	b.WriteString("+")
+	b.WriteString(strings.Repeat("-", valueWidth+1))
+	b.WriteString("\n")
+	for i, cell := range cells {
+		name := ""
+		if i < len(f.columns) {
+			name = f.columns[i].Name
+		}
+		// Continuation lines of multi-line values leave the name column empty
+		for _, line := range strings.Split(cell, "\n") {
+			if name != "" && f.opts.HeaderStyle != "" {
+				b.WriteString(styled(name, f.opts.HeaderStyle))
+			} else {
+				b.WriteString(name)
+			}
+			b.WriteString(strings.Repeat(" ", f.nameWidth-twwidth.Width(name)))
+			b.WriteString(" | ")
+			b.WriteString(line)
+			b.WriteString("\n")
+			name = ""
+		}
+	}
+	_, err := io.WriteString(f.w, b.String())
+	return err
+}
+
+// csvFormatter writes RFC 4180 CSV with a header line. NULL is written as an
+// empty field and an empty string as "", so the two can be told apart.
+type csvFormatter struct {
+	w       io.Writer
+	opts    Options
+	columns []Column
+}
+
+func (f *csvFormatter) Header(columns []Column) error {
+	f.columns = columns
+	if f.opts.NoHeader {
+		return nil
+	}
+	fields := make([]string, len(columns))
+	for i, c := range columns {
+		fields[i] = csvField(c.Name)
+	}
+	return f.writeLine(fields)
+}
+
+func (f *csvFormatter) Row(values []interface{}) error {
+	fields := make([]string, len(values))
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		var c Column
+		if i < len(f.columns) {
+			c = f.columns[i]
+		}
+		fields[i] = csvField(f.opts.formatValue(c, v))
+	}
+	// Each row is written at once so output streams as it is fetched
+	return f.writeLine(fields)
+}
+
+func (f *csvFormatter) Close() error {
+	return nil
+}
+
+func (f *csvFormatter) writeLine(fields []string) error {
+	_, err := io.WriteString(f.w, strings.Join(fields, ",")+"\n")
+	return err
+}
+
+// csvField quotes a non-NULL value if needed. Empty values are always quoted
+// because an unquoted empty field means NULL.
+func csvField(s string) string {
+	if s != "" && !strings.ContainsAny(s, ",\"\r\n") && s[0] != ' ' && s[0] != '\t' {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// jsonFormatter writes a JSON array with one object per row
+type jsonFormatter struct {
+	w       io.Writer
+	opts    Options
+	columns []Column
+	keys    [][]byte
+	rows    int
+}
+
+func (f *jsonFormatter) Header(columns []Column) error {
+	f.columns = columns
+	f.keys = make([][]byte, len(columns))
+	for i, c := range columns {
+		key, err := json.Marshal(c.Name)
+		if err != nil {
+			return err
+		}
+		f.keys[i] = key
+	}
+	_, err := io.WriteString(f.w, "[")
+	return err
+}
+
+func (f *jsonFormatter) Row(values []interface{}) error {
+	// Objects are assembled by hand to keep the columns in result order
+	buf := []byte("\n  {")
+	if f.rows > 0 {
+		buf = []byte(",\n  {")
+	}
+	for i, v := range values {
+		if i > 0 {
+			buf = append(buf, ", "...)
+		}
+		value, err := json.Marshal(f.opts.jsonValue(f.columns[i], v))
+		if err != nil {
+			return err
+		}
+		buf = append(buf, f.keys[i]...)
+		buf = append(buf, ": "...)
+		buf = append(buf, value...)
+	}
+	buf = append(buf, '}')
+	f.rows++
+	_, err := f.w.Write(buf)
+	return err
+}
+
+func (f *jsonFormatter) Close() error {
+	end := "\n]\n"
+	if f.rows == 0 {
+		end = "]\n"
+	}
+	_, err := io.WriteString(f.w, end)
+	return err
+}
@@ -0,0 +1,196 @@
+package calcitesql
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// untyped returns columns without type information
+func untyped(names ...string) []Column {
+	columns := make([]Column, len(names))
+	for i, name := range names {
+		columns[i] = Column{Name: name}
+	}
+	return columns
+}
+
+func formatRows(t *testing.T, opts Options, columns []Column, rows [][]interface{}) string {
+	t.Helper()
+	var buf bytes.Buffer
+	f, err := NewFormatter(&buf, opts)
+	if err != nil {
+		t.Fatalf("NewFormatter() error = %v", err)
+	}
+	if err := f.Header(columns); err != nil {
+		t.Fatalf("Header() error = %v", err)
+	}
+	for _, row := range rows {
+		if err := f.Row(row); err != nil {
+			t.Fatalf("Row() error = %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.String()
+}
+
+func TestFormatters(t *testing.T) {
+	columns := untyped("ID", "NAME")
+	rows := [][]interface{}{
+		{int64(1), "a,b"},
+		{int64(2), nil},
+		{int64(3), ""},
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "csv",
+			opts: Options{Format: FormatCSV},
+			want: "ID,NAME\n1,\"a,b\"\n2,\n3,\"\"\n",
+		},
+		{
+			name: "json",
+			opts: Options{Format: FormatJSON},
+			want: "[\n  {\"ID\": 1, \"NAME\": \"a,b\"},\n  {\"ID\": 2, \"NAME\": null},\n  {\"ID\": 3, \"NAME\": \"\"}\n]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatRows(t, tt.opts, columns, rows); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamingTable(t *testing.T) {
+	columns := untyped("ID", "NAME")
+	var rows [][]interface{}
+	for i := 0; i < streamSampleRows+5; i++ {
+		rows = append(rows, []interface{}{int64(i), strings.Repeat("x", i%7)})
+	}
+
+	// IDs past the sample are wider than the sized column and must be
+	// wrapped rather than cut off
+	streamed := formatRows(t, Options{Stream: true}, columns, rows)
+	for _, want := range []string{"ID", "NAME", "xxxxxx", "1↩", "04"} {
+		if !strings.Contains(streamed, want) {
+			t.Errorf("Expected streamed table to contain %q", want)
+		}
+	}
+	if lines := strings.Count(streamed, "\n"); lines < len(rows) {
+		t.Errorf("Expected at least %d lines, got %d", len(rows), lines)
+	}
+}
+
+func TestFormatterUnknown(t *testing.T) {
+	if _, err := NewFormatter(&bytes.Buffer{}, Options{Format: "xml"}); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestExpandedFormatter(t *testing.T) {
+	columns := untyped("ID", "DESCRIPTION")
+	rows := [][]interface{}{
+		{int64(1), "first\nsecond"},
+		{int64(2), nil},
+	}
+
+	got := formatRows(t, Options{Expanded: ExpandedOn}, columns, rows)
+	want := "-[ RECORD 1 ]+-------\n" +
+		"ID          | 1\n" +
+		"DESCRIPTION | first\n" +
+		"            | second\n" +
+		"-[ RECORD 2 ]+-----\n" +
+		"ID          | 2\n" +
+		"DESCRIPTION | NULL\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExpandedAuto(t *testing.T) {
+	columns := untyped("A", "B")
+	rows := [][]interface{}{{strings.Repeat("x", 30), "y"}}
+
+	narrow := formatRows(t, Options{Expanded: ExpandedAuto, TermWidth: 20}, columns, rows)
+	if !strings.HasPrefix(narrow, "-[ RECORD 1 ]") {
+		t.Errorf("Expected expanded output on a narrow terminal, got\n%s", narrow)
+	}
+
+	wide := formatRows(t, Options{Expanded: ExpandedAuto, TermWidth: 200}, columns, rows)
+	if strings.Contains(wide, "RECORD") {
+		t.Errorf("Expected a table on a wide terminal, got\n%s", wide)
+	}
+
+	streamed := formatRows(t, Options{Expanded: ExpandedAuto, TermWidth: 20, Stream: true}, columns, rows)
+	if !strings.HasPrefix(streamed, "-[ RECORD 1 ]") {
+		t.Errorf("Expected expanded output when streaming on a narrow terminal, got\n%s", streamed)
+	}
+}
+
+func TestNullDisplay(t *testing.T) {
+	columns := untyped("A", "B")
+	rows := [][]interface{}{{nil, "NULL"}}
+	nullText, emptySet := "<null>", "∅"
+
+	got := formatRows(t, Options{NullString: &nullText}, columns, rows)
+	if !strings.Contains(got, "│ <null> │ NULL │") {
+		t.Errorf("Expected NULL shown as <null>, got\n%s", got)
+	}
+
+	empty := ""
+	got = formatRows(t, Options{NullString: &empty}, columns, rows)
+	if !strings.Contains(got, "│   │ NULL │") {
+		t.Errorf("Expected NULL shown as an empty cell, got\n%s", got)
+	}
+
+	got = formatRows(t, Options{ColorNull: true}, columns, rows)
+	if !strings.Contains(got, ansiDim+"NULL"+ansiReset) || strings.Count(got, ansiDim) != 1 {
+		t.Errorf("Expected only the SQL NULL to be dimmed, got %q", got)
+	}
+
+	got = formatRows(t, Options{ColorNull: true, NullStyle: "1;31"}, columns, rows)
+	if !strings.Contains(got, "\x1b[1;31mNULL"+ansiReset) {
+		t.Errorf("Expected NULL in the given style, got %q", got)
+	}
+
+	got = formatRows(t, Options{Expanded: ExpandedOn, NullString: &emptySet}, columns, rows)
+	if !strings.Contains(got, "A | ∅\n") {
+		t.Errorf("Expected expanded output to use the NULL string, got\n%s", got)
+	}
+
+	got = formatRows(t, Options{Format: FormatCSV, NullString: &nullText}, columns, rows)
+	if got != "A,B\n,NULL\n" {
+		t.Errorf("Expected CSV to leave NULL empty, got %q", got)
+	}
+}
+
+func TestHeaderStyle(t *testing.T) {
+	columns := untyped("ORDER_ID", "B")
+	rows := [][]interface{}{{"1", "x"}}
+
+	plain := formatRows(t, Options{}, columns, rows)
+	for _, stream := range []bool{false, true} {
+		got := formatRows(t, Options{HeaderStyle: "1", Stream: stream}, columns, rows)
+		if !strings.Contains(got, "\x1b[1mORDER ID"+ansiReset) {
+			t.Errorf("Expected a styled title-case header, got %q", got)
+		}
+		// The escape sequences take no room in the layout
+		if unstyled := strings.ReplaceAll(strings.ReplaceAll(got, "\x1b[1m", ""), ansiReset, ""); unstyled != plain {
+			t.Errorf("Expected the styled table to line up like the plain one, got\n%s\nwant\n%s", unstyled, plain)
+		}
+	}
+
+	got := formatRows(t, Options{HeaderStyle: "1", Expanded: ExpandedOn}, columns, rows)
+	if !strings.Contains(got, "\x1b[1mORDER_ID"+ansiReset+" | 1\n") || !strings.Contains(got, "\x1b[1mB"+ansiReset+"        | x\n") {
+		t.Errorf("Expected styled names in expanded output, got %q", got)
+	}
+}
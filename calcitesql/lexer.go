@@ -0,0 +1,191 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package calcitesql
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind identifies the lexical class of a Token
+type TokenKind int
+
+const (
+	TokenWhitespace TokenKind = iota
+	TokenComment
+	TokenWord        // keyword or unquoted identifier
+	TokenQuotedIdent // "identifier" or `identifier`
+	TokenString      // 'string literal'
+	TokenNumber
+	TokenPunct // ( ) , ; .
+	TokenOperator
+)
+
+// Token is a lexical element of a SQL text
+type Token struct {
+	Kind TokenKind
+	Text string
+	// Pos is the byte offset of the token in the input
+	Pos int
+	// Unterminated is set for strings, quoted identifiers and block comments
+	// that run to the end of the input without being closed
+	Unterminated bool
+}
+
+// Upper returns the token text in upper case, which is how keywords are compared
+func (t Token) Upper() string {
+	return strings.ToUpper(t.Text)
+}
+
+// IsWord reports whether the token is the bare word w, ignoring case
+func (t Token) IsWord(w string) bool {
+	return t.Kind == TokenWord && strings.EqualFold(t.Text, w)
+}
+
+// Tokenize splits a SQL text into tokens. It never fails: malformed input such
+// as an unclosed quote produces a token marked Unterminated, so the lexer can
+// be used on partially typed statements.
+func Tokenize(sql string) []Token {
+	var tokens []Token
+	for pos := 0; pos < len(sql); {
+		r, size := utf8.DecodeRuneInString(sql[pos:])
+		start := pos
+		tok := Token{Pos: start}
+
+		switch {
+		case unicode.IsSpace(r):
+			tok.Kind = TokenWhitespace
+			pos = scanWhile(sql, pos, unicode.IsSpace)
+		case strings.HasPrefix(sql[pos:], "--"):
+			tok.Kind = TokenComment
+			if end := strings.IndexByte(sql[pos:], '\n'); end >= 0 {
+				pos += end
+			} else {
+				pos = len(sql)
+			}
+		case strings.HasPrefix(sql[pos:], "/*"):
+			tok.Kind = TokenComment
+			if end := strings.Index(sql[pos+2:], "*/"); end >= 0 {
+				pos += end + 4
+			} else {
+				pos = len(sql)
+				tok.Unterminated = true
+			}
+		case r == '\'':
+			tok.Kind = TokenString
+			pos, tok.Unterminated = scanQuoted(sql, pos, '\'')
+		case r == '"' || r == '`':
+			tok.Kind = TokenQuotedIdent
+			pos, tok.Unterminated = scanQuoted(sql, pos, byte(r))
+		case unicode.IsDigit(r) || (r == '.' && pos+1 < len(sql) && isDigit(sql[pos+1])):
+			tok.Kind = TokenNumber
+			pos = scanNumber(sql, pos)
+		case unicode.IsLetter(r) || r == '_':
+			tok.Kind = TokenWord
+			pos = scanWhile(sql, pos, isWordRune)
+		case strings.ContainsRune("(),;.", r):
+			tok.Kind = TokenPunct
+			pos += size
+		default:
+			tok.Kind = TokenOperator
+			pos += size
+			if pos < len(sql) && isTwoCharOperator(sql[start:pos+1]) {
+				pos++
+			}
+		}
+
+		tok.Text = sql[start:pos]
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+// SignificantTokens returns the tokens of sql without whitespace and comments
+func SignificantTokens(sql string) []Token {
+	var tokens []Token
+	for _, tok := range Tokenize(sql) {
+		if tok.Kind != TokenWhitespace && tok.Kind != TokenComment {
+			tokens = append(tokens, tok)
+		}
+	}
+	return tokens
+}
+
+func scanWhile(sql string, pos int, f func(rune) bool) int {
+	for pos < len(sql) {
+		r, size := utf8.DecodeRuneInString(sql[pos:])
+		if !f(r) {
+			break
+		}
+		pos += size
+	}
+	return pos
+}
+
+// scanQuoted consumes a quoted token starting at pos, where a doubled quote
+// character is an escaped quote
+func scanQuoted(sql string, pos int, quote byte) (int, bool) {
+	pos++
+	for pos < len(sql) {
+		if sql[pos] == quote {
+			if pos+1 < len(sql) && sql[pos+1] == quote {
+				pos += 2
+				continue
+			}
+			return pos + 1, false
+		}
+		pos++
+	}
+	return pos, true
+}
+
+func scanNumber(sql string, pos int) int {
+	for pos < len(sql) && (isDigit(sql[pos]) || sql[pos] == '.') {
+		pos++
+	}
+	if pos < len(sql) && (sql[pos] == 'e' || sql[pos] == 'E') {
+		exp := pos + 1
+		if exp < len(sql) && (sql[exp] == '+' || sql[exp] == '-') {
+			exp++
+		}
+		if exp < len(sql) && isDigit(sql[exp]) {
+			pos = exp
+			for pos < len(sql) && isDigit(sql[pos]) {
+				pos++
+			}
+		}
+	}
+	return pos
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$'
+}
+
+func isTwoCharOperator(op string) bool {
+	switch op {
+	case "<=", ">=", "<>", "!=", "||", "::":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package calcitesql
+
+import (
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	type tok struct {
+		kind TokenKind
+		text string
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  []tok
+	}{
+		{
+			name:  "simple select",
+			input: "SELECT a, 1.5e3 FROM t;",
+			want: []tok{
+				{TokenWord, "SELECT"}, {TokenWhitespace, " "}, {TokenWord, "a"}, {TokenPunct, ","},
+				{TokenWhitespace, " "}, {TokenNumber, "1.5e3"}, {TokenWhitespace, " "}, {TokenWord, "FROM"},
+				{TokenWhitespace, " "}, {TokenWord, "t"}, {TokenPunct, ";"},
+			},
+		},
+		{
+			name:  "quotes and comments",
+			input: "'it''s' \"My Col\" -- note\n/* block */",
+			want: []tok{
+				{TokenString, "'it''s'"}, {TokenWhitespace, " "}, {TokenQuotedIdent, "\"My Col\""},
+				{TokenWhitespace, " "}, {TokenComment, "-- note"}, {TokenWhitespace, "\n"}, {TokenComment, "/* block */"},
+			},
+		},
+		{
+			name:  "operators",
+			input: "a<>b||c",
+			want: []tok{
+				{TokenWord, "a"}, {TokenOperator, "<>"}, {TokenWord, "b"}, {TokenOperator, "||"}, {TokenWord, "c"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Tokenize(tt.input)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Tokenize(%q) returned %d tokens, want %d: %v", tt.input, len(got), len(tt.want), got)
+			}
+			for i, g := range got {
+				if g.Kind != tt.want[i].kind || g.Text != tt.want[i].text {
+					t.Errorf("token %d = (%d, %q), want (%d, %q)", i, g.Kind, g.Text, tt.want[i].kind, tt.want[i].text)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenizeUnterminated(t *testing.T) {
+	for _, input := range []string{"SELECT 'abc", "SELECT \"col", "SELECT /* comment"} {
+		tokens := Tokenize(input)
+		last := tokens[len(tokens)-1]
+		if !last.Unterminated {
+			t.Errorf("Expected last token of %q to be unterminated, got %+v", input, last)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package calcitesql
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Binary formats accepted in Options.BinaryFormat
+const (
+	BinaryHex    = "hex"
+	BinaryBase64 = "base64"
+)
+
+// BinaryFormats lists the supported binary formats
+var BinaryFormats = []string{BinaryHex, BinaryBase64}
+
+// ParseTimeZone returns the location for an IANA zone name such as
+// Europe/Berlin, UTC or Local. An empty name or "default" returns nil, which
+// keeps the zone returned by the driver.
+func ParseTimeZone(name string) (*time.Location, error) {
+	switch {
+	case name == "" || strings.EqualFold(name, "default"):
+		return nil, nil
+	case strings.EqualFold(name, "local"):
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// Column describes a result column
+type Column struct {
+	Name string
+	// DatabaseType is the SQL type reported by the driver in upper case,
+	// e.g. VARCHAR or INTEGER ARRAY; empty if unknown
+	DatabaseType string
+}
+
+// IsNumeric reports whether the column holds numbers, which are right-aligned
+func (c Column) IsNumeric() bool {
+	switch c.DatabaseType {
+	case "TINYINT", "SMALLINT", "INTEGER", "INT", "BIGINT", "DECIMAL", "NUMERIC",
+		"FLOAT", "REAL", "DOUBLE", "UNSIGNED_TINYINT", "UNSIGNED_SMALLINT",
+		"UNSIGNED_INT", "UNSIGNED_LONG", "UNSIGNED_FLOAT", "UNSIGNED_DOUBLE":
+		return true
+	}
+	return false
+}
+
+// elementColumn returns the column describing the elements of an ARRAY column
+func (c Column) elementColumn() Column {
+	return Column{Name: c.Name, DatabaseType: strings.TrimSpace(strings.TrimSuffix(c.DatabaseType, "ARRAY"))}
+}
+
+// resultColumns describes the columns of rows, falling back to bare names when
+// the driver does not report types
+func resultColumns(rows *sql.Rows) ([]Column, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		names, err := rows.Columns()
+		if err != nil {
+			return nil, err
+		}
+		columns := make([]Column, len(names))
+		for i, name := range names {
+			columns[i] = Column{Name: name}
+		}
+		return columns, nil
+	}
+	columns := make([]Column, len(types))
+	for i, t := range types {
+		columns[i] = Column{Name: t.Name(), DatabaseType: strings.ToUpper(t.DatabaseTypeName())}
+	}
+	return columns, nil
+}
+
+func columnNames(columns []Column) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// nullText is printed for SQL NULL by the text based formats
+func (o Options) nullText() string {
+	if o.NullString == nil {
+		return "NULL"
+	}
+	return *o.NullString
+}
+
+// formatValue renders a single value of column c for the text based formats
+func (o Options) formatValue(c Column, v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return o.nullText()
+	case string:
+		return x
+	case time.Time:
+		return o.formatTime(c, x)
+	case []byte:
+		return o.formatBytes(c, x)
+	case float32:
+		return o.formatFloat(float64(x), 32)
+	case float64:
+		return o.formatFloat(x, 64)
+	}
+
+	// ARRAY values arrive as slices
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		elem := c.elementColumn()
+		items := make([]string, rv.Len())
+		for i := range items {
+			items[i] = o.formatValue(elem, rv.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// formatTime renders dates, times and timestamps in ISO-8601
+func (o Options) formatTime(c Column, t time.Time) string {
+	switch c.DatabaseType {
+	case "DATE", "UNSIGNED_DATE":
+		return t.Format("2006-01-02")
+	case "TIME", "UNSIGNED_TIME":
+		return t.Format("15:04:05.999999999")
+	}
+	// Only points in time are moved to the display time zone, a DATE or TIME
+	// has no zone to convert from
+	if o.TimeZone != nil {
+		t = t.In(o.TimeZone)
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// formatBytes renders BINARY and VARBINARY values in the configured encoding.
+// Drivers may also return text as bytes, which is printed as is.
+func (o Options) formatBytes(c Column, b []byte) string {
+	binary := strings.Contains(c.DatabaseType, "BINARY") || c.DatabaseType == "BYTES"
+	if !binary && (c.DatabaseType != "" || utf8.Valid(b)) {
+		return string(b)
+	}
+	if o.BinaryFormat == BinaryBase64 {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return hex.EncodeToString(b)
+}
+
+func (o Options) formatFloat(f float64, bitSize int) string {
+	if o.FloatPrecision > 0 && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'f', o.FloatPrecision, bitSize)
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
+
+// jsonValue keeps numbers, booleans, arrays and NULL as JSON types and
+// renders everything else as a string
+func (o Options) jsonValue(c Column, v interface{}) interface{} {
+	switch n := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return v
+	case float32:
+		return o.jsonValue(c, float64(n))
+	case float64:
+		// JSON has no representation for NaN and infinities
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return o.formatValue(c, v)
+		}
+		return v
+	case string, []byte:
+		return o.formatValue(c, v)
+	}
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		elem := c.elementColumn()
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = o.jsonValue(elem, rv.Index(i).Interface())
+		}
+		return items
+	}
+	return o.formatValue(c, v)
+}
@@ -0,0 +1,81 @@
+package calcitesql
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatValue(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 30, 45, 500000000, time.UTC)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	tests := []struct {
+		name   string
+		opts   Options
+		column Column
+		value  interface{}
+		want   string
+	}{
+		{"null", Options{}, Column{DatabaseType: "VARCHAR"}, nil, "NULL"},
+		{"string", Options{}, Column{DatabaseType: "VARCHAR"}, "abc", "abc"},
+		{"integer", Options{}, Column{DatabaseType: "INTEGER"}, int64(42), "42"},
+		{"date", Options{}, Column{DatabaseType: "DATE"}, ts, "2024-03-01"},
+		{"time", Options{}, Column{DatabaseType: "TIME"}, ts, "12:30:45.5"},
+		{"timestamp", Options{}, Column{DatabaseType: "TIMESTAMP"}, ts, "2024-03-01T12:30:45.5Z"},
+		{"timestamp in zone", Options{TimeZone: berlin}, Column{DatabaseType: "TIMESTAMP"}, ts, "2024-03-01T13:30:45.5+01:00"},
+		{"date ignores zone", Options{TimeZone: berlin}, Column{DatabaseType: "DATE"}, ts, "2024-03-01"},
+		{"binary hex", Options{}, Column{DatabaseType: "VARBINARY"}, []byte{0xca, 0xfe}, "cafe"},
+		{"binary base64", Options{BinaryFormat: BinaryBase64}, Column{DatabaseType: "BINARY"}, []byte{0xca, 0xfe}, "yv4="},
+		{"text bytes", Options{}, Column{DatabaseType: "VARCHAR"}, []byte("abc"), "abc"},
+		{"untyped invalid bytes", Options{}, Column{}, []byte{0xff}, "ff"},
+		{"double", Options{}, Column{DatabaseType: "DOUBLE"}, 0.1, "0.1"},
+		{"float", Options{}, Column{DatabaseType: "FLOAT"}, float32(0.1), "0.1"},
+		{"double precision", Options{FloatPrecision: 2}, Column{DatabaseType: "DOUBLE"}, 2.0 / 3, "0.67"},
+		{"nan precision", Options{FloatPrecision: 2}, Column{DatabaseType: "DOUBLE"}, math.NaN(), "NaN"},
+		{"array", Options{}, Column{DatabaseType: "INTEGER ARRAY"}, []interface{}{int64(1), nil}, "[1, NULL]"},
+		{"timestamp array", Options{}, Column{DatabaseType: "TIMESTAMP ARRAY"}, []time.Time{ts}, "[2024-03-01T12:30:45.5Z]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.formatValue(tt.column, tt.value); got != tt.want {
+				t.Errorf("formatValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONValue(t *testing.T) {
+	opts := Options{}
+	if v := opts.jsonValue(Column{}, math.NaN()); v != "NaN" {
+		t.Errorf("Expected NaN to be rendered as a string, got %v", v)
+	}
+	if v := opts.jsonValue(Column{}, float32(1.5)); v != 1.5 {
+		t.Errorf("Expected float32 to stay a number, got %v", v)
+	}
+	if v := opts.jsonValue(Column{DatabaseType: "VARBINARY"}, []byte{1}); v != "01" {
+		t.Errorf("Expected binary to be hex encoded, got %v", v)
+	}
+	got := opts.jsonValue(Column{DatabaseType: "INTEGER ARRAY"}, []int64{1, 2})
+	if want := []interface{}{int64(1), int64(2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected array to stay an array, got %v", got)
+	}
+}
+
+func TestNumericAlignment(t *testing.T) {
+	columns := []Column{{Name: "N", DatabaseType: "INTEGER"}, {Name: "S", DatabaseType: "VARCHAR"}}
+	rows := [][]interface{}{{int64(1), "a"}, {int64(100), "bbb"}}
+
+	for _, opts := range []Options{{}, {Stream: true}} {
+		got := formatRows(t, opts, columns, rows)
+		if !strings.Contains(got, "│   1 │ a   │") {
+			t.Errorf("Expected numbers right-aligned and text left-aligned (stream=%v), got\n%s", opts.Stream, got)
+		}
+	}
+}
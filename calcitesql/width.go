@@ -0,0 +1,158 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package calcitesql
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter/pkg/twwarp"
+	"github.com/olekukonko/tablewriter/pkg/twwidth"
+)
+
+// Overflow modes accepted in Options.Overflow
+const (
+	OverflowTruncate = "truncate"
+	OverflowWrap     = "wrap"
+)
+
+// Overflows lists the supported overflow modes
+var Overflows = []string{OverflowTruncate, OverflowWrap}
+
+// minFitWidth is the narrowest a column is made when fitting a table to the
+// terminal; tables that cannot fit even so are left to the pager
+const minFitWidth = 8
+
+// layout fixes the width limit of every column from the options and, without
+// a MaxColWidth, from the terminal width. Columns are never made narrower than
+// their name.
+func (f *tableFormatter) layout() {
+	f.limits = make([]int, len(f.columns))
+	fixed := 0
+	var fit []int
+	for i, c := range f.columns {
+		switch {
+		case f.opts.ColumnWidths[c.Name] > 0:
+			f.limits[i] = f.opts.ColumnWidths[c.Name]
+		case f.opts.MaxColWidth > 0:
+			f.limits[i] = f.opts.MaxColWidth
+		default:
+			fit = append(fit, i)
+			continue
+		}
+		fixed += min(f.widths[i], f.limits[i])
+	}
+	if f.termWidth > 0 && len(fit) > 0 {
+		f.fitTerminal(fit, fixed)
+	}
+	for i, c := range f.columns {
+		if f.limits[i] > 0 {
+			f.limits[i] = max(f.limits[i], twwidth.Width(c.Name))
+		}
+	}
+}
+
+// borderWidth is the width a table of n columns takes besides the values:
+// each column is drawn as "│ value " and the table is closed with "│"
+func borderWidth(n int) int {
+	return 3*n + 1
+}
+
+// fitTerminal limits the columns in fit so the table fits the terminal, given
+// the width already taken by the other columns
+func (f *tableFormatter) fitTerminal(fit []int, fixed int) {
+	available := f.termWidth - borderWidth(len(f.columns)) - fixed
+	widths := make([]int, len(fit))
+	for k, i := range fit {
+		widths[k] = f.widths[i]
+	}
+	if limit := fitWidth(widths, available); limit > 0 {
+		for _, i := range fit {
+			f.limits[i] = limit
+		}
+	}
+}
+
+// fitWidth returns the largest width limit that makes columns of the given
+// widths fit in available, or 0 if they fit without one. Only the widest
+// columns are affected, narrow ones keep their size.
+func fitWidth(widths []int, available int) int {
+	sorted := append([]int(nil), widths...)
+	sort.Ints(sorted)
+	for k, w := range sorted {
+		left := len(sorted) - k
+		if w*left > available {
+			return max(available/left, minFitWidth)
+		}
+		available -= w
+	}
+	return 0
+}
+
+func (f *tableFormatter) fitRow(cells []string) []string {
+	fitted := make([]string, len(cells))
+	for i, cell := range cells {
+		fitted[i] = cell
+		if i < len(f.limits) {
+			fitted[i] = fitCell(cell, f.limits[i], f.opts.Overflow)
+		}
+	}
+	return fitted
+}
+
+// fitCell shortens each line of cell to limit, cutting it off with an
+// ellipsis or wrapping it onto further lines
+func fitCell(cell string, limit int, overflow string) string {
+	if limit <= 0 || twwidth.Width(cell) <= limit {
+		return cell
+	}
+	var lines []string
+	for _, line := range strings.Split(cell, "\n") {
+		if twwidth.Width(line) <= limit {
+			lines = append(lines, line)
+			continue
+		}
+		if overflow != OverflowWrap {
+			lines = append(lines, twwidth.Truncate(line, limit, "…"))
+			continue
+		}
+		wrapped, _ := twwarp.WrapString(line, limit)
+		for _, w := range wrapped {
+			lines = append(lines, breakLine(w, limit)...)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// breakLine splits a line without spaces that is still wider than limit
+func breakLine(line string, limit int) []string {
+	var lines []string
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		rw := twwidth.Width(string(r))
+		if width+rw > limit && width > 0 {
+			lines = append(lines, b.String())
+			b.Reset()
+			width = 0
+		}
+		b.WriteRune(r)
+		width += rw
+	}
+	return append(lines, b.String())
+}
@@ -0,0 +1,86 @@
+package calcitesql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFitCell(t *testing.T) {
+	tests := []struct {
+		name     string
+		cell     string
+		limit    int
+		overflow string
+		want     string
+	}{
+		{"fits", "abc", 5, OverflowTruncate, "abc"},
+		{"unlimited", "abcdefgh", 0, OverflowTruncate, "abcdefgh"},
+		{"truncate", "abcdefgh", 5, OverflowTruncate, "abcd…"},
+		{"truncate lines", "abcdefgh\nxy", 5, "", "abcd…\nxy"},
+		{"wrap words", "aaa bbb ccc", 7, OverflowWrap, "aaa bbb\nccc"},
+		{"wrap long word", "abcdefgh", 3, OverflowWrap, "abc\ndef\ngh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fitCell(tt.cell, tt.limit, tt.overflow); got != tt.want {
+				t.Errorf("fitCell() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitWidth(t *testing.T) {
+	tests := []struct {
+		name      string
+		widths    []int
+		available int
+		want      int
+	}{
+		{"fits", []int{10, 20}, 30, 0},
+		{"narrow widest", []int{10, 50, 60}, 70, 30},
+		{"minimum", []int{50, 50}, 4, minFitWidth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fitWidth(tt.widths, tt.available); got != tt.want {
+				t.Errorf("fitWidth() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnWidths(t *testing.T) {
+	columns := untyped("ID", "DESCRIPTION")
+	rows := [][]interface{}{{int64(1), strings.Repeat("x", 60)}}
+
+	got := formatRows(t, Options{MaxColWidth: 10}, columns, rows)
+	if !strings.Contains(got, "│ xxxxxxxxxx… │") {
+		t.Errorf("Expected the value truncated to the width of the column name, got\n%s", got)
+	}
+
+	got = formatRows(t, Options{MaxColWidth: 10, ColumnWidths: map[string]int{"DESCRIPTION": 20}}, columns, rows)
+	if !strings.Contains(got, "│ "+strings.Repeat("x", 19)+"… │") {
+		t.Errorf("Expected the column override to win, got\n%s", got)
+	}
+
+	// Column names are matched exactly, as quoted names may differ in case only
+	got = formatRows(t, Options{MaxColWidth: 10, ColumnWidths: map[string]int{"DESCRIPTION": 20}}, untyped("ID", "description"), rows)
+	if !strings.Contains(got, "│ xxxxxxxxxx… │") {
+		t.Errorf("Expected the override of another column to be ignored, got\n%s", got)
+	}
+
+	got = formatRows(t, Options{MaxColWidth: 30, Overflow: OverflowWrap}, columns, rows)
+	if strings.Count(got, "│ "+strings.Repeat("x", 30)+" │") != 2 {
+		t.Errorf("Expected the value wrapped onto two lines, got\n%s", got)
+	}
+
+	got = formatRows(t, Options{TermWidth: 40, Stream: true}, columns, rows)
+	for _, line := range strings.Split(strings.TrimSpace(got), "\n") {
+		if w := len([]rune(line)); w > 40 {
+			t.Errorf("Expected the table to fit 40 columns, got a line of %d:\n%s", w, got)
+			break
+		}
+	}
+}
@@ -19,12 +19,17 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	avatica "github.com/apache/calcite-avatica-go/v5"
+	calcitesql "github.com/satyakommula96/calcite-cli/calcitesql"
 	prompt "github.com/satyakommula96/calcite-cli/prompt"
 	"github.com/spf13/cobra"
 )
@@ -38,6 +43,45 @@ type ConnectionConfig struct {
 	Passwd           string
 	MaxRowsTotal     string
 	CustomParams     string
+	ShowConnectionID bool
+	Isolation        string
+	ReadOnly         bool
+	Safe             bool
+	AssumeYes        bool
+	MaxRows          int
+	Format           string
+	Stream           bool
+	NoPager          bool
+	Timing           bool
+	Quiet            bool
+	Output           string
+	NoMetadataCache  bool
+	CompletionMatch  string
+	KeywordCase      string
+	Dialect          string
+	NoHighlight      bool
+	Theme            string
+	Themes           map[string]map[string]string
+	MetadataCacheTTL time.Duration
+	MaxColWidth      int
+	Overflow         string
+	NullString       string
+	TimeZone         string
+	BinaryFormat     string
+	FloatPrecision   int
+	ConfigPath       string
+	Profile          string
+}
+
+// isolationLevels maps --isolation values onto the JDBC constants expected by
+// the transactionIsolation DSN parameter. "default" leaves it out.
+var isolationLevels = map[string]string{
+	"default":          "",
+	"none":             "0",
+	"read-uncommitted": "1",
+	"read-committed":   "2",
+	"repeatable-read":  "4",
+	"serializable":     "8",
 }
 
 var config = ConnectionConfig{
@@ -49,7 +93,25 @@ func main() {
 	rootCmd := &cobra.Command{
 		Use:   "calcite cli",
 		Short: "A calcite CLI prompt to execute queries",
-		Run:   runSQLPrompt,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// The config file is optional unless a profile is asked for
+			cfg, err := loadConfigFile(config.ConfigPath)
+			if errors.Is(err, fs.ErrNotExist) && config.Profile == "" {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			config.Themes = cfg.Themes
+			if err := applyTheme(cmd.Flags(), cfg); err != nil {
+				return err
+			}
+			if config.Profile == "" {
+				return nil
+			}
+			return applyProfile(cmd.Flags(), cfg, config.Profile)
+		},
+		Run: runSQLPrompt,
 	}
 
 	// Define flags for connection URL and additional parameters
@@ -62,6 +124,33 @@ func main() {
 	rootCmd.MarkFlagsRequiredTogether("username", "password")
 	rootCmd.Flags().StringVarP(&config.MaxRowsTotal, "maxRowsTotal", "m", "", "The maxRowsTotal parameter sets the maximum number of rows to return for a given query")
 	rootCmd.Flags().StringVar(&config.CustomParams, "extra_params", "", "Custom connection parameters for avatica connection (ex: \"parameter1=value;...parameterN=value\")")
+	rootCmd.Flags().StringVar(&config.Isolation, "isolation", "default", "Transaction isolation level of the connection (default, none, read-uncommitted, read-committed, repeatable-read, serializable)")
+	rootCmd.Flags().BoolVar(&config.ReadOnly, "read-only", false, "Make the connection read-only on the server")
+	rootCmd.Flags().BoolVar(&config.Safe, "safe", false, "Refuse statements that could modify data")
+	rootCmd.Flags().IntVar(&config.MaxRows, "max-rows", 1000, "Stop displaying results after this many rows, 0 for no limit")
+	rootCmd.Flags().StringVarP(&config.Format, "format", "f", calcitesql.FormatTable, "Result output format ("+strings.Join(calcitesql.Formats, ", ")+")")
+	rootCmd.Flags().BoolVar(&config.Stream, "stream", false, "Print table rows as they are fetched instead of after the whole result")
+	rootCmd.Flags().IntVar(&config.MaxColWidth, "max-col-width", 0, "Widest a table column may be, 0 narrows wide columns to fit the terminal")
+	rootCmd.Flags().StringVar(&config.Overflow, "overflow", calcitesql.OverflowTruncate, "What to do with values wider than their column ("+strings.Join(calcitesql.Overflows, ", ")+")")
+	rootCmd.Flags().StringVar(&config.NullString, "null-string", "NULL", "Text shown for NULL values in tables")
+	rootCmd.Flags().StringVar(&config.TimeZone, "timezone", "", "Time zone for displaying TIMESTAMP values (ex: UTC, Local, Europe/Berlin)")
+	rootCmd.Flags().StringVar(&config.BinaryFormat, "binary-format", calcitesql.BinaryHex, "Encoding of BINARY and VARBINARY values ("+strings.Join(calcitesql.BinaryFormats, ", ")+")")
+	rootCmd.Flags().IntVar(&config.FloatPrecision, "float-precision", 0, "Decimals shown for FLOAT and DOUBLE values, 0 for the shortest exact form")
+	rootCmd.Flags().StringVarP(&config.Output, "output", "o", "", "Write query results to this file instead of the terminal")
+	rootCmd.Flags().BoolVar(&config.Timing, "timing", true, "Report query, first row and fetch times after each result")
+	rootCmd.Flags().BoolVarP(&config.Quiet, "quiet", "q", false, "Leave out the row count and timing footer")
+	rootCmd.Flags().BoolVar(&config.NoPager, "no-pager", false, "Never show results through $PAGER")
+	rootCmd.Flags().BoolVarP(&config.AssumeYes, "yes", "y", false, "Run destructive statements without asking for confirmation")
+	rootCmd.Flags().StringVar(&config.ConfigPath, "config", defaultConfigPath(), "Path of the configuration file holding profiles and themes")
+	rootCmd.Flags().StringVarP(&config.Profile, "profile", "P", "", "Name of the configuration file profile to use")
+	rootCmd.Flags().StringVar(&config.CompletionMatch, "completion-match", prompt.MatchFuzzy, "How completions match the typed word (fuzzy, prefix)")
+	rootCmd.Flags().StringVar(&config.KeywordCase, "keyword-case", prompt.KeywordUpper, "Case of completed keywords and functions (upper, lower)")
+	rootCmd.Flags().StringVar(&config.Dialect, "dialect", prompt.DialectAuto, "SQL dialect of completed keywords and functions (auto, calcite, phoenix, druid, hive)")
+	rootCmd.Flags().BoolVar(&config.NoHighlight, "no-highlight", false, "Show the input line without syntax highlighting")
+	rootCmd.Flags().StringVar(&config.Theme, "theme", prompt.ThemeDark, "Colour theme of the prompt and results (dark, light, high-contrast, mono, or a theme of the config file)")
+	rootCmd.Flags().BoolVar(&config.NoMetadataCache, "no-metadata-cache", false, "Load completion metadata from the server instead of the on-disk cache")
+	rootCmd.Flags().DurationVar(&config.MetadataCacheTTL, "metadata-cache-ttl", 24*time.Hour, "How long cached completion metadata is used before it is reloaded")
+	rootCmd.Flags().BoolVar(&config.ShowConnectionID, "show-connection-id", false, "Show the Avatica connection ID of the session in the prompt")
 
 	err := rootCmd.Execute()
 	if err != nil {
@@ -70,19 +159,100 @@ func main() {
 }
 
 func runSQLPrompt(cmd *cobra.Command, args []string) {
+	timeZone, err := calcitesql.ParseTimeZone(config.TimeZone)
+	if err != nil {
+		log.Fatalf("Invalid --timezone: %v", err)
+	}
+	if !slices.Contains(calcitesql.Overflows, config.Overflow) {
+		log.Fatalf("Invalid --overflow %q, expected one of %s", config.Overflow, strings.Join(calcitesql.Overflows, ", "))
+	}
+	if !slices.Contains(calcitesql.BinaryFormats, config.BinaryFormat) {
+		log.Fatalf("Invalid --binary-format %q, expected one of %s", config.BinaryFormat, strings.Join(calcitesql.BinaryFormats, ", "))
+	}
+	if !slices.Contains(prompt.MatchModes, config.CompletionMatch) {
+		log.Fatalf("Invalid --completion-match %q, expected one of %s", config.CompletionMatch, strings.Join(prompt.MatchModes, ", "))
+	}
+	if !slices.Contains(prompt.KeywordCases, config.KeywordCase) {
+		log.Fatalf("Invalid --keyword-case %q, expected one of %s", config.KeywordCase, strings.Join(prompt.KeywordCases, ", "))
+	}
+	config.Isolation = strings.ToLower(config.Isolation)
+	if _, ok := isolationLevels[config.Isolation]; !ok {
+		log.Fatalf("Invalid --isolation %q, expected one of default, none, read-uncommitted, read-committed, repeatable-read, serializable", config.Isolation)
+	}
+	if !slices.Contains(prompt.Dialects, config.Dialect) {
+		log.Fatalf("Invalid --dialect %q, expected one of %s", config.Dialect, strings.Join(prompt.Dialects, ", "))
+	}
+	theme, err := prompt.ResolveTheme(config.Theme, config.Themes)
+	if err != nil {
+		log.Fatalf("Invalid --theme: %v", err)
+	}
+
 	// Establish a connection to the calcite server
 	db := establishConnection(config)
 	defer db.Close()
 
 	// Create and run the SQL prompt
-	prompt.CreateAndRunPrompt(db)
+	pager := "auto"
+	if config.NoPager {
+		pager = "off"
+	}
+	var metadataCache string
+	if !config.NoMetadataCache {
+		metadataCache = metadataCachePath(config)
+	}
+
+	prompt.CreateAndRunPrompt(db, prompt.Options{
+		ShowConnectionID: config.ShowConnectionID,
+		Safe:             config.Safe,
+		AssumeYes:        config.AssumeYes,
+		ReadOnly:         config.ReadOnly,
+		Isolation:        config.Isolation,
+		Pager:            pager,
+		Output:           config.Output,
+		DSN:              buildConnectionURL(config),
+		MetadataCache:    metadataCache,
+		Match:            config.CompletionMatch,
+		KeywordCase:      config.KeywordCase,
+		Dialect:          config.Dialect,
+		Highlight:        !config.NoHighlight,
+		Theme:            &theme,
+		MetadataCacheTTL: config.MetadataCacheTTL,
+		Display: calcitesql.Options{
+			MaxRows:        config.MaxRows,
+			Format:         config.Format,
+			Stream:         config.Stream,
+			Timing:         config.Timing,
+			Quiet:          config.Quiet,
+			MaxColWidth:    config.MaxColWidth,
+			Overflow:       config.Overflow,
+			NullString:     &config.NullString,
+			ColorNull:      true,
+			TimeZone:       timeZone,
+			BinaryFormat:   config.BinaryFormat,
+			FloatPrecision: config.FloatPrecision,
+		},
+	})
 }
 
 func establishConnection(cfg ConnectionConfig) *sql.DB {
 	dsn := buildConnectionURL(cfg)
 	fmt.Println("Connecting to ", dsn)
 
-	// Prepare the info map
+	// Create a new connector
+	connector := avatica.NewConnector(dsn).(*avatica.Connector)
+
+	// Set the info map in the connector
+	connector.Info = buildConnectionInfo(cfg)
+
+	// Open the database using the connector
+	db := sql.OpenDB(connector)
+	fmt.Println("Connected")
+	return db
+}
+
+// buildConnectionInfo prepares the properties sent with the Avatica
+// OpenConnectionRequest
+func buildConnectionInfo(cfg ConnectionConfig) map[string]string {
 	info := make(map[string]string)
 	if cfg.CustomParams != "" {
 		pairs := strings.Split(cfg.CustomParams, ";")
@@ -94,17 +264,13 @@ func establishConnection(cfg ConnectionConfig) *sql.DB {
 		}
 	}
 
-	// Create a new connector
-	connector := avatica.NewConnector(dsn).(*avatica.Connector)
-
-	// Set the info map in the connector
-	connector.Info = info
-
-	// Open the database using the connector
-	db := sql.OpenDB(connector)
-	fmt.Println("Connected")
-	return db
+	// avatica-go cannot sync the read-only flag itself, so pass it as a connection property
+	if cfg.ReadOnly {
+		info["readOnly"] = "true"
+	}
+	return info
 }
+
 func buildConnectionURL(cfg ConnectionConfig) string {
 	u, err := url.Parse(cfg.ConnectionURL)
 	if err != nil {
@@ -135,6 +301,16 @@ func buildConnectionURL(cfg ConnectionConfig) string {
 		q.Set("maxRowsTotal", cfg.MaxRowsTotal)
 	}
 
+	if cfg.Isolation != "" {
+		level, ok := isolationLevels[strings.ToLower(cfg.Isolation)]
+		if !ok {
+			log.Fatalf("Invalid isolation level: %s", cfg.Isolation)
+		}
+		if level != "" {
+			q.Set("transactionIsolation", level)
+		}
+	}
+
 	// Add connection parameters
 	if cfg.ConnectionParams != "" {
 		extraQ, err := url.ParseQuery(strings.ReplaceAll(cfg.ConnectionParams, ";", "&"))
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -46,6 +47,30 @@ func TestBuildConnectionURL(t *testing.T) {
 			},
 			want: "http://localhost:8080/myschema?avaticaPassword=pass1&avaticaUser=user1&maxRowsTotal=1000&serialization=protobuf",
 		},
+		{
+			name: "with isolation",
+			cfg: ConnectionConfig{
+				ConnectionURL: "http://localhost:8080",
+				Isolation:     "Serializable",
+			},
+			want: "http://localhost:8080?transactionIsolation=8",
+		},
+		{
+			name: "with no isolation",
+			cfg: ConnectionConfig{
+				ConnectionURL: "http://localhost:8080",
+				Isolation:     "none",
+			},
+			want: "http://localhost:8080?transactionIsolation=0",
+		},
+		{
+			name: "with default isolation",
+			cfg: ConnectionConfig{
+				ConnectionURL: "http://localhost:8080",
+				Isolation:     "default",
+			},
+			want: "http://localhost:8080",
+		},
 	}
 
 	for _, tt := range tests {
@@ -57,3 +82,40 @@ func TestBuildConnectionURL(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildConnectionInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ConnectionConfig
+		want map[string]string
+	}{
+		{
+			name: "no properties",
+			cfg:  ConnectionConfig{},
+			want: map[string]string{},
+		},
+		{
+			name: "custom params",
+			cfg: ConnectionConfig{
+				CustomParams: "a=1;b=x=y;invalid",
+			},
+			want: map[string]string{"a": "1", "b": "x=y"},
+		},
+		{
+			name: "read only",
+			cfg: ConnectionConfig{
+				ReadOnly: true,
+			},
+			want: map[string]string{"readOnly": "true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildConnectionInfo(tt.cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildConnectionInfo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/pflag"
+)
+
+// ConfigFile is the JSON configuration file. Each profile maps flag names to
+// the values used when the flag is not given on the command line, e.g.
+//
+//	{"profiles": {"prod": {"url": "http://phoenix:8765", "safe": true}}}
+//
+// Theme is the default colour theme and Themes defines themes of its own,
+// each a base theme and the colours it overrides, e.g.
+//
+//	{"theme": "mine", "themes": {"mine": {"base": "light", "keyword": "dark_red"}}}
+type ConfigFile struct {
+	Profiles map[string]map[string]interface{} `json:"profiles"`
+	Theme    string                            `json:"theme"`
+	Themes   map[string]map[string]string      `json:"themes"`
+}
+
+// defaultConfigPath returns ~/.config/calcite-cli/config.json or the platform equivalent
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "calcite-cli", "config.json")
+}
+
+// metadataCachePath returns the file completion metadata is cached in,
+// named after the server, schema and user so that connections never share
+// their metadata
+func metadataCachePath(cfg ConnectionConfig) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(cfg.ConnectionURL + "\x00" + cfg.Schema + "\x00" + cfg.User))
+	return filepath.Join(dir, "calcite-cli", hex.EncodeToString(sum[:8])+".json")
+}
+
+func loadConfigFile(path string) (*ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg ConfigFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// applyTheme makes the theme of the config file the default of --theme. The
+// flag is not marked as changed, so a profile can still choose its own theme.
+func applyTheme(flags *pflag.FlagSet, cfg *ConfigFile) error {
+	flag := flags.Lookup("theme")
+	if cfg.Theme == "" || flag.Changed {
+		return nil
+	}
+	if err := flag.Value.Set(cfg.Theme); err != nil {
+		return err
+	}
+	flag.DefValue = cfg.Theme
+	return nil
+}
+
+// applyProfile sets every flag named in the profile that was not given
+// explicitly, so command line flags always take precedence over the profile.
+func applyProfile(flags *pflag.FlagSet, cfg *ConfigFile, name string) error {
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	for key, value := range profile {
+		flag := flags.Lookup(key)
+		if flag == nil {
+			return fmt.Errorf("profile %q: unknown option %q", name, key)
+		}
+		if flag.Changed {
+			continue
+		}
+		if err := flags.Set(key, profileValue(value)); err != nil {
+			return fmt.Errorf("profile %q: invalid value for %q: %w", name, key, err)
+		}
+	}
+	return nil
+}
+
+// profileValue formats a JSON value the way it would be given on the command
+// line. JSON numbers decode as float64, which fmt prints with an exponent
+// from a million up.
+func profileValue(value interface{}) string {
+	if f, ok := value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestApplyProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"profiles": {"prod": {"url": "http://phoenix:8765", "safe": true, "schema": "SALES", "max-rows": 1000000, "ratio": 0.5}}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Unexpected error writing config: %s", err)
+	}
+
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading config: %s", err)
+	}
+
+	var url, schema string
+	var safe bool
+	var maxRows int
+	var ratio float64
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVar(&url, "url", "http://localhost:8080", "")
+	flags.StringVar(&schema, "schema", "", "")
+	flags.BoolVar(&safe, "safe", false, "")
+	flags.IntVar(&maxRows, "max-rows", 0, "")
+	flags.Float64Var(&ratio, "ratio", 0, "")
+	if err := flags.Parse([]string{"--schema", "OTHER"}); err != nil {
+		t.Fatalf("Unexpected error parsing flags: %s", err)
+	}
+
+	if err := applyProfile(flags, cfg, "prod"); err != nil {
+		t.Fatalf("Unexpected error applying profile: %s", err)
+	}
+	if url != "http://phoenix:8765" {
+		t.Errorf("Expected url from profile, got %q", url)
+	}
+	if !safe {
+		t.Error("Expected safe mode from profile")
+	}
+	if maxRows != 1000000 || ratio != 0.5 {
+		t.Errorf("Expected numbers from profile, got %d and %g", maxRows, ratio)
+	}
+	if schema != "OTHER" {
+		t.Errorf("Expected command line schema to win over profile, got %q", schema)
+	}
+
+	if err := applyProfile(flags, cfg, "missing"); err == nil {
+		t.Error("Expected an error for a missing profile")
+	}
+	cfg.Profiles["bad"] = map[string]interface{}{"nope": 1}
+	if err := applyProfile(flags, cfg, "bad"); err == nil {
+		t.Error("Expected an error for an unknown option")
+	}
+}
+
+func TestMetadataCachePath(t *testing.T) {
+	base := ConnectionConfig{ConnectionURL: "http://phoenix:8765", Schema: "SALES", User: "alice"}
+	path := metadataCachePath(base)
+	if filepath.Base(filepath.Dir(path)) != "calcite-cli" || filepath.Ext(path) != ".json" {
+		t.Errorf("Unexpected cache path %q", path)
+	}
+	if again := metadataCachePath(base); again != path {
+		t.Errorf("Expected a stable path, got %q and %q", path, again)
+	}
+
+	for _, other := range []ConnectionConfig{
+		{ConnectionURL: "http://phoenix:8766", Schema: "SALES", User: "alice"},
+		{ConnectionURL: "http://phoenix:8765", Schema: "HR", User: "alice"},
+		{ConnectionURL: "http://phoenix:8765", Schema: "SALES", User: "bob"},
+	} {
+		if metadataCachePath(other) == path {
+			t.Errorf("Expected %+v to use its own cache file", other)
+		}
+	}
+}
+
+func TestApplyTheme(t *testing.T) {
+	cfg := &ConfigFile{Theme: "light"}
+
+	var theme string
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVar(&theme, "theme", "dark", "")
+	if err := applyTheme(flags, cfg); err != nil {
+		t.Fatalf("Unexpected error applying theme: %s", err)
+	}
+	if theme != "light" {
+		t.Errorf("Expected theme from config file, got %q", theme)
+	}
+
+	if err := flags.Parse([]string{"--theme", "mono"}); err != nil {
+		t.Fatalf("Unexpected error parsing flags: %s", err)
+	}
+	if err := applyTheme(flags, cfg); err != nil {
+		t.Fatalf("Unexpected error applying theme: %s", err)
+	}
+	if theme != "mono" {
+		t.Errorf("Expected command line theme to win over config file, got %q", theme)
+	}
+}
+
+func TestApplyThemeWithProfile(t *testing.T) {
+	cfg := &ConfigFile{
+		Theme: "light",
+		Profiles: map[string]map[string]interface{}{
+			"prod":  {"theme": "mono"},
+			"plain": {"url": "http://phoenix:8765"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		profile string
+		want    string
+	}{
+		{"config file default", nil, "", "light"},
+		{"profile theme", nil, "prod", "mono"},
+		{"profile without theme", nil, "plain", "light"},
+		{"command line theme", []string{"--theme", "dark"}, "prod", "dark"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var theme, url string
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.StringVar(&theme, "theme", "dark", "")
+			flags.StringVar(&url, "url", "", "")
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatalf("Unexpected error parsing flags: %s", err)
+			}
+			if err := applyTheme(flags, cfg); err != nil {
+				t.Fatalf("Unexpected error applying theme: %s", err)
+			}
+			if tt.profile != "" {
+				if err := applyProfile(flags, cfg, tt.profile); err != nil {
+					t.Fatalf("Unexpected error applying profile: %s", err)
+				}
+			}
+			if theme != tt.want {
+				t.Errorf("Expected theme %q, got %q", tt.want, theme)
+			}
+		})
+	}
+}
@@ -6,8 +6,11 @@ require (
 	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/apache/calcite-avatica-go/v5 v5.4.0
 	github.com/c-bata/go-prompt v0.2.6
+	github.com/google/uuid v1.6.0
 	github.com/olekukonko/tablewriter v1.1.4
 	github.com/spf13/cobra v1.9.1
+	github.com/spf13/pflag v1.0.6
+	google.golang.org/protobuf v1.36.6
 )
 
 require (
@@ -16,7 +19,7 @@ require (
 	github.com/mattn/go-tty v0.0.7 // indirect
 	github.com/pkg/term v1.2.0-beta.2 // indirect
 	golang.org/x/net v0.47.0 // indirect
-	golang.org/x/sys v0.38.0 // indirect
+	golang.org/x/sys v0.38.0
 )
 
 require (
@@ -24,7 +27,6 @@ require (
 	github.com/clipperhouse/displaywidth v0.10.0 // indirect
 	github.com/clipperhouse/uax29/v2 v2.6.0 // indirect
 	github.com/fatih/color v1.18.0 // indirect
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/hashicorp/go-uuid v1.0.3 // indirect
 	github.com/icholy/digest v1.1.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
@@ -38,9 +40,10 @@ require (
 	github.com/olekukonko/cat v0.0.0-20250911104152-50322a0618f6 // indirect
 	github.com/olekukonko/errors v1.2.0 // indirect
 	github.com/olekukonko/ll v0.1.6 // indirect
-	github.com/spf13/pflag v1.0.6 // indirect
 	golang.org/x/crypto v0.45.0 // indirect
-	google.golang.org/protobuf v1.36.6 // indirect
 )
 
+// The input line highlighting in prompt/highlight.go relies on the order in
+// which this fork's Render writes a line: EraseDown, then the prefix, then
+// the input. Check it again before moving to another go-prompt version.
 replace github.com/c-bata/go-prompt v0.2.6 => github.com/aranjan7/go-prompt v0.2.7
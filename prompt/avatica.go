@@ -0,0 +1,294 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	avatica "github.com/apache/calcite-avatica-go/v5"
+	"github.com/apache/calcite-avatica-go/v5/message"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// Avatica names protobuf messages after the Java classes they map to
+const (
+	avaticaRequestPrefix  = "org.apache.calcite.avatica.proto.Requests$"
+	avaticaResponsePrefix = "org.apache.calcite.avatica.proto.Responses$"
+)
+
+// requestTimeout bounds metadata loads and connection requests, so that a
+// server that stops answering cannot block the prompt
+const requestTimeout = 30 * time.Second
+
+// avaticaClient sends the metadata RPCs that database/sql has no API for.
+// It speaks the protobuf protocol used by avatica-go to the same server, so
+// it is not available with JSON serialization.
+type avaticaClient struct {
+	endpoint string
+	http     *http.Client
+	// info holds the properties connections are opened with
+	info map[string]string
+}
+
+// newAvaticaClient prepares a client for the server and credentials of an
+// avatica-go DSN
+func newAvaticaClient(dsn string) (*avaticaClient, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	if s := q.Get("serialization"); s != "" && !strings.EqualFold(s, "protobuf") {
+		return nil, fmt.Errorf("metadata requests need protobuf serialization, not %s", s)
+	}
+	info := map[string]string{}
+	if user := q.Get("avaticaUser"); user != "" {
+		info["user"] = user
+	}
+	if password := q.Get("avaticaPassword"); password != "" {
+		info["password"] = password
+	}
+	if schema := strings.Trim(u.Path, "/"); schema != "" {
+		info["schema"] = schema
+	}
+	u.User = nil
+	u.Path = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	client := &http.Client{Transport: http.DefaultTransport}
+	switch strings.ToUpper(q.Get("authentication")) {
+	case "":
+	case "BASIC":
+		client = avatica.WithBasicAuth(client, q.Get("avaticaUser"), q.Get("avaticaPassword"))
+	case "DIGEST":
+		client = avatica.WithDigestAuth(client, q.Get("avaticaUser"), q.Get("avaticaPassword"))
+	default:
+		return nil, fmt.Errorf("%s authentication is not supported for metadata requests", q.Get("authentication"))
+	}
+	return &avaticaClient{endpoint: u.String(), http: client, info: info}, nil
+}
+
+// avaticaClassName returns the name Avatica uses for a request message
+func avaticaClassName(req proto.Message) string {
+	return avaticaRequestPrefix + string(req.ProtoReflect().Descriptor().Name())
+}
+
+func (c *avaticaClient) post(ctx context.Context, req proto.Message) (proto.Message, error) {
+	wrapped, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := proto.Marshal(message.WireMessage_builder{
+		Name:           avaticaClassName(req),
+		WrappedMessage: wrapped,
+	}.Build())
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-google-protobuf")
+	res, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Avatica reports errors with an ErrorResponse and a 500 status, anything
+	// else that failed comes from the server or a proxy in front of it
+	wire := &message.WireMessage{}
+	err = proto.Unmarshal(data, wire)
+	if res.StatusCode != http.StatusOK && (err != nil || !strings.HasSuffix(wire.GetName(), "$ErrorResponse")) {
+		return nil, fmt.Errorf("server returned %s", res.Status)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid response from server: %w", err)
+	}
+	var resp proto.Message
+	switch strings.TrimPrefix(wire.GetName(), avaticaResponsePrefix) {
+	case "ResultSetResponse":
+		resp = &message.ResultSetResponse{}
+	case "FetchResponse":
+		resp = &message.FetchResponse{}
+	case "CloseStatementResponse":
+		resp = &message.CloseStatementResponse{}
+	case "DatabasePropertyResponse":
+		resp = &message.DatabasePropertyResponse{}
+	case "ConnectionSyncResponse":
+		resp = &message.ConnectionSyncResponse{}
+	case "OpenConnectionResponse":
+		resp = &message.OpenConnectionResponse{}
+	case "CloseConnectionResponse":
+		resp = &message.CloseConnectionResponse{}
+	case "ErrorResponse":
+		resp = &message.ErrorResponse{}
+	default:
+		return nil, fmt.Errorf("unexpected response %s", wire.GetName())
+	}
+	if err := proto.Unmarshal(wire.GetWrappedMessage(), resp); err != nil {
+		return nil, fmt.Errorf("invalid response from server: %w", err)
+	}
+	if e, ok := resp.(*message.ErrorResponse); ok {
+		return nil, errors.New(e.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+// metadataRow is a row of a metadata result set, keyed by column label
+type metadataRow map[string]*message.TypedValue
+
+func (r metadataRow) String(column string) string {
+	return r[column].GetStringValue()
+}
+
+// resultSet sends a metadata request and reads every frame of the result
+func (c *avaticaClient) resultSet(ctx context.Context, connectionID string, req proto.Message) ([]metadataRow, error) {
+	resp, err := c.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	rs, ok := resp.(*message.ResultSetResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response to %s", avaticaClassName(req))
+	}
+	if rs.GetOwnStatement() {
+		defer c.post(ctx, message.CloseStatementRequest_builder{
+			ConnectionId: connectionID,
+			StatementId:  rs.GetStatementId(),
+		}.Build())
+	}
+
+	var labels []string
+	for _, column := range rs.GetSignature().GetColumns() {
+		labels = append(labels, column.GetLabel())
+	}
+
+	var rows []metadataRow
+	frame := rs.GetFirstFrame()
+	for frame != nil {
+		for _, row := range frame.GetRows() {
+			r := metadataRow{}
+			for i, value := range row.GetValue() {
+				if i >= len(labels) {
+					break
+				}
+				if v := value.GetScalarValue(); v != nil {
+					r[labels[i]] = v
+				} else if len(value.GetValue()) > 0 {
+					// Older servers send scalars as a one element list
+					r[labels[i]] = value.GetValue()[0]
+				}
+			}
+			rows = append(rows, r)
+		}
+		if frame.GetDone() {
+			break
+		}
+
+		resp, err := c.post(ctx, message.FetchRequest_builder{
+			ConnectionId: connectionID,
+			StatementId:  rs.GetStatementId(),
+			Offset:       frame.GetOffset() + uint64(len(frame.GetRows())),
+		}.Build())
+		if err != nil {
+			return nil, err
+		}
+		fetch, ok := resp.(*message.FetchResponse)
+		if !ok {
+			return nil, errors.New("unexpected response to FetchRequest")
+		}
+		frame = fetch.GetFrame()
+	}
+	return rows, nil
+}
+
+// databaseProperties returns the string properties of getDatabaseProperties,
+// such as GET_DATABASE_PRODUCT_NAME, keyed by name
+func (c *avaticaClient) databaseProperties(ctx context.Context, connectionID string) (map[string]string, error) {
+	resp, err := c.post(ctx, message.DatabasePropertyRequest_builder{
+		ConnectionId: connectionID,
+	}.Build())
+	if err != nil {
+		return nil, err
+	}
+	dp, ok := resp.(*message.DatabasePropertyResponse)
+	if !ok {
+		return nil, errors.New("unexpected response to DatabasePropertyRequest")
+	}
+	props := map[string]string{}
+	for _, prop := range dp.GetProps() {
+		props[prop.GetKey().GetName()] = prop.GetValue().GetStringValue()
+	}
+	return props, nil
+}
+
+// openConnection opens a connection of its own on the server and returns
+// its ID
+func (c *avaticaClient) openConnection(ctx context.Context) (string, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	_, err = c.post(ctx, message.OpenConnectionRequest_builder{
+		ConnectionId: id.String(),
+		Info:         c.info,
+	}.Build())
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// closeConnection closes a connection opened with openConnection
+func (c *avaticaClient) closeConnection(ctx context.Context, connectionID string) error {
+	_, err := c.post(ctx, message.CloseConnectionRequest_builder{
+		ConnectionId: connectionID,
+	}.Build())
+	return err
+}
+
+// syncConnection makes a connection read-only if asked to and sets its
+// transaction isolation to a JDBC level, leaving it unchanged when zero
+func (c *avaticaClient) syncConnection(ctx context.Context, connectionID string, readOnly bool, isolation uint32) error {
+	_, err := c.post(ctx, message.ConnectionSyncRequest_builder{
+		ConnectionId: connectionID,
+		ConnProps: message.ConnectionProperties_builder{
+			ReadOnly:             readOnly,
+			HasReadOnly:          readOnly,
+			TransactionIsolation: isolation,
+		}.Build(),
+	}.Build())
+	return err
+}
@@ -0,0 +1,292 @@
+package prompt
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/apache/calcite-avatica-go/v5/message"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeResult is a metadata result served by fakeAvatica
+type fakeResult struct {
+	columns []string
+	rows    [][]string
+}
+
+// fakeAvatica answers metadata requests with fixed results. The first frame
+// holds a single row so that the rest has to be fetched.
+type fakeAvatica struct {
+	results map[string]fakeResult
+	// props are the database properties, or nil when not supported
+	props   map[string]string
+	mu      sync.Mutex
+	fetched []string
+	closed  int
+	auth    string
+	// synced are the properties of each ConnectionSyncRequest
+	synced []*message.ConnectionProperties
+	// opened are the IDs of the connections opened and not closed yet
+	opened []string
+}
+
+func (f *fakeAvatica) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, _ := io.ReadAll(r.Body)
+	wire := &message.WireMessage{}
+	if err := proto.Unmarshal(data, wire); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimPrefix(wire.GetName(), avaticaRequestPrefix)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = r.Header.Get("Authorization")
+
+	var resp proto.Message
+	switch name {
+	case "FetchRequest":
+		req := &message.FetchRequest{}
+		_ = proto.Unmarshal(wire.GetWrappedMessage(), req)
+		last := f.fetched[req.GetStatementId()]
+		resp = message.FetchResponse_builder{
+			Frame: frame(f.results[last].rows, 1, true),
+		}.Build()
+	case "ConnectionSyncRequest":
+		req := &message.ConnectionSyncRequest{}
+		_ = proto.Unmarshal(wire.GetWrappedMessage(), req)
+		f.synced = append(f.synced, req.GetConnProps())
+		resp = message.ConnectionSyncResponse_builder{ConnProps: req.GetConnProps()}.Build()
+	case "OpenConnectionRequest":
+		req := &message.OpenConnectionRequest{}
+		_ = proto.Unmarshal(wire.GetWrappedMessage(), req)
+		f.opened = append(f.opened, req.GetConnectionId())
+		resp = message.OpenConnectionResponse_builder{}.Build()
+	case "CloseConnectionRequest":
+		req := &message.CloseConnectionRequest{}
+		_ = proto.Unmarshal(wire.GetWrappedMessage(), req)
+		f.opened = slices.DeleteFunc(f.opened, func(id string) bool { return id == req.GetConnectionId() })
+		resp = message.CloseConnectionResponse_builder{}.Build()
+	case "CloseStatementRequest":
+		f.closed++
+		resp = message.CloseStatementResponse_builder{}.Build()
+	case "DatabasePropertyRequest":
+		if f.props == nil {
+			resp = message.ErrorResponse_builder{ErrorMessage: name + " not supported"}.Build()
+			break
+		}
+		var props []*message.DatabasePropertyElement
+		for key, value := range f.props {
+			props = append(props, message.DatabasePropertyElement_builder{
+				Key:   message.DatabaseProperty_builder{Name: key}.Build(),
+				Value: message.TypedValue_builder{Type: message.Rep_STRING, StringValue: value}.Build(),
+			}.Build())
+		}
+		resp = message.DatabasePropertyResponse_builder{Props: props}.Build()
+	default:
+		result, ok := f.results[name]
+		if !ok {
+			resp = message.ErrorResponse_builder{ErrorMessage: name + " not supported"}.Build()
+			break
+		}
+		var columns []*message.ColumnMetaData
+		for _, c := range result.columns {
+			columns = append(columns, message.ColumnMetaData_builder{Label: c, ColumnName: c}.Build())
+		}
+		f.fetched = append(f.fetched, name)
+		resp = message.ResultSetResponse_builder{
+			StatementId:  uint32(len(f.fetched) - 1),
+			OwnStatement: true,
+			Signature:    message.Signature_builder{Columns: columns}.Build(),
+			FirstFrame:   frame(result.rows, 0, len(result.rows) <= 1),
+		}.Build()
+	}
+
+	// Avatica answers errors with a 500 status
+	if _, ok := resp.(*message.ErrorResponse); ok {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	wrapped, _ := proto.Marshal(resp)
+	body, _ := proto.Marshal(message.WireMessage_builder{
+		Name:           avaticaResponsePrefix + string(resp.ProtoReflect().Descriptor().Name()),
+		WrappedMessage: wrapped,
+	}.Build())
+	_, _ = w.Write(body)
+}
+
+// frame returns the first row of rows, or the rows from offset when done
+func frame(rows [][]string, offset int, done bool) *message.Frame {
+	if !done {
+		rows = rows[:1]
+	} else {
+		rows = rows[offset:]
+	}
+	var out []*message.Row
+	for _, row := range rows {
+		var values []*message.ColumnValue
+		for _, v := range row {
+			typed := message.TypedValue_builder{Type: message.Rep_STRING, StringValue: v}
+			if v == "" {
+				typed = message.TypedValue_builder{Type: message.Rep_NULL, Null: true}
+			}
+			values = append(values, message.ColumnValue_builder{ScalarValue: typed.Build()}.Build())
+		}
+		out = append(out, message.Row_builder{Value: values}.Build())
+	}
+	return message.Frame_builder{Offset: uint64(offset), Done: done, Rows: out}.Build()
+}
+
+func TestAvaticaMetadata(t *testing.T) {
+	fake := &fakeAvatica{results: map[string]fakeResult{
+		"CatalogsRequest": {[]string{"TABLE_CAT"}, [][]string{{"CAT"}}},
+		"SchemasRequest": {[]string{"TABLE_SCHEM", "TABLE_CATALOG"}, [][]string{
+			{"SALES", "CAT"}, {"EMPTY", "CAT"},
+		}},
+		"TablesRequest": {[]string{"TABLE_CAT", "TABLE_SCHEM", "TABLE_NAME", "TABLE_TYPE"}, [][]string{
+			{"CAT", "SALES", "ORDERS", "TABLE"},
+			{"CAT", "SALES", "BIG_ORDERS", "VIEW"},
+			{"CAT", "metadata", "TABLES", "SYSTEM TABLE"},
+		}},
+		"ColumnsRequest": {[]string{"TABLE_CAT", "TABLE_SCHEM", "TABLE_NAME", "COLUMN_NAME", "DATA_TYPE", "TYPE_NAME", "IS_NULLABLE"}, [][]string{
+			{"CAT", "SALES", "ORDERS", "ID", "4", "INTEGER", "NO"},
+			{"CAT", "SALES", "ORDERS", "NOTE", "12", "VARCHAR", "YES"},
+		}},
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	rpc, err := newAvaticaClient(server.URL + "/?authentication=BASIC&avaticaUser=u&avaticaPassword=p")
+	if err != nil {
+		t.Fatalf("Unexpected error creating client: %s", err)
+	}
+	m, err := fetchAvaticaTables(context.Background(), rpc, "conn")
+	if err != nil {
+		t.Fatalf("Unexpected error fetching tables: %s", err)
+	}
+	columns, err := fetchAvaticaColumns(context.Background(), rpc, "conn", "CAT", "SALES")
+	if err != nil {
+		t.Fatalf("Unexpected error fetching columns: %s", err)
+	}
+	m = m.withColumns(columns)
+
+	if !reflect.DeepEqual(m.catalogs, []string{"CAT"}) {
+		t.Errorf("Expected catalog CAT, got %v", m.catalogs)
+	}
+	if got := m.schemas(); !reflect.DeepEqual(got, []string{"SALES", "EMPTY", "metadata"}) {
+		t.Errorf("Expected schemas including EMPTY, got %v", got)
+	}
+	var kinds []string
+	for _, table := range m.tables {
+		kinds = append(kinds, table.kind)
+	}
+	if !reflect.DeepEqual(kinds, []string{"TABLE", "VIEW", "SYSTEM TABLE"}) {
+		t.Errorf("Expected all fetched tables with their types, got %v", kinds)
+	}
+	orders := m.lookupTable([]string{"cat", "sales", "orders"})
+	if len(orders) != 1 {
+		t.Fatalf("Expected CAT.SALES.ORDERS to be found, got %v", orders)
+	}
+	want := []columnInfo{{"ID", "INTEGER", "NO"}, {"NOTE", "VARCHAR", "YES"}}
+	if !reflect.DeepEqual(orders[0].columns, want) {
+		t.Errorf("Expected columns %v, got %v", want, orders[0].columns)
+	}
+
+	if fake.closed != 4 {
+		t.Errorf("Expected the 4 metadata statements to be closed, got %d", fake.closed)
+	}
+	if !strings.HasPrefix(fake.auth, "Basic ") {
+		t.Errorf("Expected basic authentication, got %q", fake.auth)
+	}
+}
+
+func TestAvaticaMetadataFallback(t *testing.T) {
+	// A server without metadata RPCs falls back to INFORMATION_SCHEMA
+	server := httptest.NewServer(&fakeAvatica{})
+	defer server.Close()
+
+	rpc, err := newAvaticaClient(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error creating client: %s", err)
+	}
+	if _, err := fetchAvaticaTables(context.Background(), rpc, "conn"); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("Expected the server error to be returned, got %v", err)
+	}
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<html>upstream unavailable</html>", http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+	rpc, err = newAvaticaClient(proxy.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error creating client: %s", err)
+	}
+	if _, err := fetchAvaticaTables(context.Background(), rpc, "conn"); err == nil || !strings.Contains(err.Error(), "502 Bad Gateway") {
+		t.Errorf("Expected the HTTP status to be reported, got %v", err)
+	}
+
+	if _, err := newAvaticaClient("http://localhost:8765?authentication=SPNEGO"); err == nil {
+		t.Error("Expected SPNEGO to be reported as unsupported")
+	}
+	if _, err := newAvaticaClient("http://localhost:8765?serialization=json"); err == nil {
+		t.Error("Expected JSON serialization to be reported as unsupported")
+	}
+}
+
+func TestServerFunctions(t *testing.T) {
+	fake := &fakeAvatica{
+		results: map[string]fakeResult{
+			"CatalogsRequest": {[]string{"TABLE_CAT"}, nil},
+			"SchemasRequest":  {[]string{"TABLE_SCHEM", "TABLE_CATALOG"}, nil},
+			"TablesRequest":   {[]string{"TABLE_CAT", "TABLE_SCHEM", "TABLE_NAME", "TABLE_TYPE"}, nil},
+		},
+		props: map[string]string{
+			"GET_DATABASE_PRODUCT_NAME": "Phoenix",
+			"GET_NUMERIC_FUNCTIONS":     "ABS,CBRT",
+			"GET_STRING_FUNCTIONS":      "cbrt, REGEXP_SUBSTR",
+		},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	rpc, err := newAvaticaClient(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error creating client: %s", err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery(`SYSTEM."FUNCTION"`).WillReturnRows(
+		sqlmock.NewRows([]string{"FUNCTION_NAME"}).AddRow("MY_REVERSE").AddRow("ABS"))
+
+	src := &metadataSource{db: db, rpc: rpc}
+	m := src.fetchTables(context.Background())
+	if m.product != "Phoenix" {
+		t.Errorf("Expected product Phoenix, got %q", m.product)
+	}
+	want := []string{"ABS", "CBRT", "REGEXP_SUBSTR", "MY_REVERSE"}
+	if !reflect.DeepEqual(m.functions, want) {
+		t.Errorf("Expected functions %v, got %v", want, m.functions)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expected the Phoenix function table to be queried: %s", err)
+	}
+
+	// The requests ran on a connection of their own, closed with the source
+	if len(fake.opened) != 1 || fake.opened[0] != src.connID {
+		t.Errorf("Expected the metadata connection to be opened once, got %v", fake.opened)
+	}
+	src.close()
+	if len(fake.opened) != 0 {
+		t.Errorf("Expected the metadata connection to be closed, got %v", fake.opened)
+	}
+}
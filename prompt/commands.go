@@ -0,0 +1,573 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	calcitesql "github.com/satyakommula96/calcite-cli/calcitesql"
+)
+
+// metaCommand is a backslash command handled by the prompt itself
+type metaCommand struct {
+	usage       string
+	description string
+	run         func(s *PromptSession, args []string)
+	// runLine is used instead of run by commands that parse the rest of the
+	// line themselves
+	runLine func(s *PromptSession, line string)
+}
+
+// setting is a session option that can be shown and changed with \set
+type setting struct {
+	description string
+	get         func(s *PromptSession) string
+	set         func(s *PromptSession, value string) error
+}
+
+var metaCommands map[string]metaCommand
+
+var settings = map[string]setting{
+	"binary_format": {
+		description: "Encoding of BINARY and VARBINARY values: " + strings.Join(calcitesql.BinaryFormats, ", "),
+		get:         func(s *PromptSession) string { return s.display.BinaryFormat },
+		set: func(s *PromptSession, value string) error {
+			return parseChoice(value, calcitesql.BinaryFormats, &s.display.BinaryFormat)
+		},
+	},
+	"completion_match": {
+		description: "How completions match the typed word: fuzzy also finds CUSTOMER_ID for custid or c_i, prefix only by its start",
+		get: func(s *PromptSession) string {
+			if s.match == "" {
+				return MatchFuzzy
+			}
+			return s.match
+		},
+		set: func(s *PromptSession, value string) error {
+			return parseChoice(value, MatchModes, &s.match)
+		},
+	},
+	"confirm": {
+		description: "Ask before running DROP, DELETE without WHERE and other destructive statements",
+		get:         func(s *PromptSession) string { return formatBool(s.confirm) },
+		set: func(s *PromptSession, value string) error {
+			return parseBool(value, &s.confirm)
+		},
+	},
+	"dialect": {
+		description: "SQL dialect of completed keywords and functions: " + strings.Join(Dialects, ", "),
+		get: func(s *PromptSession) string {
+			if s.dialect == DialectAuto {
+				return DialectAuto + " (" + s.activeDialect + ")"
+			}
+			return s.dialect
+		},
+		set: func(s *PromptSession, value string) error {
+			var name string
+			if err := parseChoice(value, Dialects, &name); err != nil {
+				return err
+			}
+			s.setDialect(name)
+			return nil
+		},
+	},
+	"expanded": {
+		description: "Show each row as a block of column | value lines: on, off or auto",
+		get:         func(s *PromptSession) string { return s.display.Expanded },
+		set: func(s *PromptSession, value string) error {
+			return parseChoice(value, calcitesql.ExpandedModes, &s.display.Expanded)
+		},
+	},
+	"float_precision": {
+		description: "Decimals shown for FLOAT and DOUBLE values, 0 for the shortest exact form",
+		get:         func(s *PromptSession) string { return strconv.Itoa(s.display.FloatPrecision) },
+		set: func(s *PromptSession, value string) error {
+			return parseCount(value, &s.display.FloatPrecision)
+		},
+	},
+	"format": {
+		description: "Result output format: " + strings.Join(calcitesql.Formats, ", "),
+		get:         func(s *PromptSession) string { return s.display.Format },
+		set: func(s *PromptSession, value string) error {
+			return parseChoice(value, calcitesql.Formats, &s.display.Format)
+		},
+	},
+	"highlight": {
+		description: "Colour keywords, names, strings, numbers and comments in the input line",
+		get:         func(s *PromptSession) string { return formatBool(s.highlight) },
+		set: func(s *PromptSession, value string) error {
+			return parseBool(value, &s.highlight)
+		},
+	},
+	"keyword_case": {
+		description: "Case of completed keywords and functions: " + strings.Join(KeywordCases, ", "),
+		get: func(s *PromptSession) string {
+			if s.keywordCase == "" {
+				return KeywordUpper
+			}
+			return s.keywordCase
+		},
+		set: func(s *PromptSession, value string) error {
+			return parseChoice(value, KeywordCases, &s.keywordCase)
+		},
+	},
+	"max_col_width": {
+		description: "Widest a table column may be, auto to narrow wide columns to fit the terminal",
+		get: func(s *PromptSession) string {
+			if s.display.MaxColWidth == 0 {
+				return "auto"
+			}
+			return strconv.Itoa(s.display.MaxColWidth)
+		},
+		set: func(s *PromptSession, value string) error {
+			if strings.EqualFold(value, "auto") {
+				s.display.MaxColWidth = 0
+				return nil
+			}
+			return parseCount(value, &s.display.MaxColWidth)
+		},
+	},
+	"max_rows": {
+		description: "Stop fetching results after this many rows, 0 for no limit",
+		get:         func(s *PromptSession) string { return strconv.Itoa(s.display.MaxRows) },
+		set: func(s *PromptSession, value string) error {
+			return parseCount(value, &s.display.MaxRows)
+		},
+	},
+	"null": {
+		description: "Text shown for NULL in tables; CSV leaves NULL empty and JSON uses null",
+		get: func(s *PromptSession) string {
+			switch {
+			case s.display.NullString == nil:
+				return "NULL"
+			case *s.display.NullString == "":
+				return `""`
+			}
+			return *s.display.NullString
+		},
+		set: func(s *PromptSession, value string) error {
+			text := unquote(value)
+			s.display.NullString = &text
+			return nil
+		},
+	},
+	"null_color": {
+		description: "Dim NULL in tables to tell it apart from text that reads the same",
+		get:         func(s *PromptSession) string { return formatBool(s.display.ColorNull) },
+		set: func(s *PromptSession, value string) error {
+			return parseBool(value, &s.display.ColorNull)
+		},
+	},
+	"overflow": {
+		description: "What to do with values wider than their column: " + strings.Join(calcitesql.Overflows, ", "),
+		get:         func(s *PromptSession) string { return s.display.Overflow },
+		set: func(s *PromptSession, value string) error {
+			return parseChoice(value, calcitesql.Overflows, &s.display.Overflow)
+		},
+	},
+	"pager": {
+		description: "Page long results through $PAGER: auto, on or off",
+		get:         func(s *PromptSession) string { return s.pager },
+		set: func(s *PromptSession, value string) error {
+			return parseChoice(value, pagerModes, &s.pager)
+		},
+	},
+	"quiet": {
+		description: "Leave out the row count and timing footer",
+		get:         func(s *PromptSession) string { return formatBool(s.display.Quiet) },
+		set: func(s *PromptSession, value string) error {
+			return parseBool(value, &s.display.Quiet)
+		},
+	},
+	"safe": {
+		description: "Refuse statements that could modify data",
+		get:         func(s *PromptSession) string { return formatBool(s.safe) },
+		set: func(s *PromptSession, value string) error {
+			return parseBool(value, &s.safe)
+		},
+	},
+	"stream": {
+		description: "Print table rows as they are fetched, sizing columns from the first rows",
+		get:         func(s *PromptSession) string { return formatBool(s.display.Stream) },
+		set: func(s *PromptSession, value string) error {
+			return parseBool(value, &s.display.Stream)
+		},
+	},
+	"timing": {
+		description: "Report query, first row and fetch times after each result",
+		get:         func(s *PromptSession) string { return formatBool(s.display.Timing) },
+		set: func(s *PromptSession, value string) error {
+			return parseBool(value, &s.display.Timing)
+		},
+	},
+	"timezone": {
+		description: "Time zone for TIMESTAMP values, e.g. UTC, Local or Europe/Berlin; default keeps the driver's",
+		get: func(s *PromptSession) string {
+			if s.display.TimeZone == nil {
+				return "default"
+			}
+			return s.display.TimeZone.String()
+		},
+		set: func(s *PromptSession, value string) error {
+			loc, err := calcitesql.ParseTimeZone(value)
+			if err != nil {
+				return err
+			}
+			s.display.TimeZone = loc
+			return nil
+		},
+	},
+	"show_connection_id": {
+		description: "Show the Avatica connection ID in the prompt",
+		get:         func(s *PromptSession) string { return formatBool(s.showConnectionID) },
+		set: func(s *PromptSession, value string) error {
+			return parseBool(value, &s.showConnectionID)
+		},
+	},
+}
+
+func init() {
+	// Registered here rather than in the declaration because \? refers back to the table
+	metaCommands = map[string]metaCommand{
+		`\?`: {
+			usage:       `\?`,
+			description: "Show this help",
+			run:         (*PromptSession).printHelp,
+		},
+		`\safe`: {
+			usage:       `\safe [on|off]`,
+			description: "Show or toggle safe mode, which blocks writes",
+			run:         settingCommand("safe"),
+		},
+		`\set`: {
+			usage:       `\set [name [value]]`,
+			description: "Show or change session settings",
+			run:         (*PromptSession).runSet,
+		},
+		`\colwidth`: {
+			usage:       `\colwidth [column [width]]`,
+			description: "Show or override the width of a table column, 0 removes the override",
+			run:         (*PromptSession).runColWidth,
+		},
+		`\copy`: {
+			usage:       `\copy (query) TO 'file' [WITH] [csv|json] [header] [append]`,
+			description: "Export the result of a query to a file",
+			runLine:     (*PromptSession).runCopy,
+		},
+		`\conninfo`: {
+			usage:       `\conninfo`,
+			description: "Show the Avatica connection ID of this session",
+			run: func(s *PromptSession, args []string) {
+				fmt.Println("Connection ID:", s.connectionID())
+			},
+		},
+		`\d`: {
+			usage:       `\d [table]`,
+			description: "List tables and views, or describe the columns of a table",
+			runLine: func(s *PromptSession, line string) {
+				if err := s.describe(os.Stdout, line); err != nil {
+					fmt.Fprintln(os.Stderr, "Error:", err)
+				}
+			},
+		},
+		`\dn`: {
+			usage:       `\dn`,
+			description: "List schemas",
+			run: func(s *PromptSession, args []string) {
+				if err := s.describeSchemas(os.Stdout); err != nil {
+					fmt.Fprintln(os.Stderr, "Error:", err)
+				}
+			},
+		},
+		`\h`: {
+			usage:       `\h [keyword|function]`,
+			description: "Show the syntax and examples of a statement or function",
+			runLine: func(s *PromptSession, line string) {
+				if err := s.sqlHelp(os.Stdout, line); err != nil {
+					fmt.Fprintln(os.Stderr, "Error:", err)
+				}
+			},
+		},
+		`\format`: {
+			usage:       `\format [table|csv|json]`,
+			description: "Show or change the result output format",
+			run:         settingCommand("format"),
+		},
+		`\isolation`: {
+			usage:       `\isolation [level]`,
+			description: "Show or change the isolation level of the connection",
+			run:         (*PromptSession).runIsolation,
+		},
+		`\limit`: {
+			usage:       `\limit [n]`,
+			description: "Show or change the maximum number of rows displayed, 0 for no limit",
+			run:         settingCommand("max_rows"),
+		},
+		`\null`: {
+			usage:       `\null [text]`,
+			description: "Show or change the text displayed for NULL",
+			run:         settingCommand("null"),
+		},
+		`\o`: {
+			usage:       `\o [[>>]file]`,
+			description: "Send query results to a file, appending with >>, or back to the terminal",
+			run:         (*PromptSession).runOutput,
+		},
+		`\pager`: {
+			usage:       `\pager [on|off|auto]`,
+			description: "Show or change when results are shown through $PAGER",
+			run:         settingCommand("pager"),
+		},
+		`\refresh`: {
+			usage:       `\refresh [--hard]`,
+			description: "Reload table and column metadata used by completion and \\d, --hard also discards the cache",
+			run: func(s *PromptSession, args []string) {
+				switch {
+				case len(args) == 0:
+					s.catalog.refresh()
+				case len(args) == 1 && args[0] == "--hard":
+					if err := s.catalog.hardRefresh(); err != nil {
+						fmt.Fprintln(os.Stderr, "Error removing metadata cache:", err)
+						return
+					}
+				default:
+					fmt.Fprintln(os.Stderr, "Invalid argument:", strings.Join(args, " "), "(expected --hard)")
+					return
+				}
+				fmt.Println("Refreshing metadata in the background")
+			},
+		},
+		`\reconnect`: {
+			usage:       `\reconnect`,
+			description: "Acquire a new Avatica connection, discarding session state",
+			run: func(s *PromptSession, args []string) {
+				if err := s.reconnect(); err != nil {
+					fmt.Fprintln(os.Stderr, "Error reconnecting:", err)
+				}
+			},
+		},
+		`\timing`: {
+			usage:       `\timing [on|off]`,
+			description: "Toggle or set reporting of query and fetch times; the row count stays unless quiet is set",
+			run: func(s *PromptSession, args []string) {
+				if len(args) == 0 {
+					args = []string{formatBool(!s.display.Timing)}
+				}
+				if s.setSetting("timing", strings.Join(args, " ")) {
+					fmt.Println("Timing is", formatBool(s.display.Timing))
+				}
+			},
+		},
+		`\x`: {
+			usage:       `\x [on|off|auto]`,
+			description: "Toggle or set expanded display, end a statement with \\G for a single query",
+			run: func(s *PromptSession, args []string) {
+				if len(args) == 0 {
+					// Without an argument \x toggles like in psql
+					if s.display.Expanded == calcitesql.ExpandedOn {
+						args = []string{calcitesql.ExpandedOff}
+					} else {
+						args = []string{calcitesql.ExpandedOn}
+					}
+				}
+				if s.setSetting("expanded", strings.Join(args, " ")) {
+					fmt.Println("Expanded display is", s.display.Expanded)
+				}
+			},
+		},
+	}
+}
+
+// runMetaCommand executes a backslash command line such as `\set name value`
+func (s *PromptSession) runMetaCommand(line string) {
+	line = strings.TrimRight(strings.TrimSpace(line), ";")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+	cmd, ok := metaCommands[fields[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Invalid command %s. Try \\? for help.\n", fields[0])
+		return
+	}
+	if cmd.runLine != nil {
+		cmd.runLine(s, strings.TrimSpace(strings.TrimPrefix(line, fields[0])))
+		return
+	}
+	cmd.run(s, fields[1:])
+}
+
+func (s *PromptSession) printHelp(args []string) {
+	names := make([]string, 0, len(metaCommands))
+	for name := range metaCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %-28s %s\n", metaCommands[name].usage, metaCommands[name].description)
+	}
+}
+
+func (s *PromptSession) runSet(args []string) {
+	switch len(args) {
+	case 0:
+		names := make([]string, 0, len(settings))
+		for name := range settings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %-20s = %-10s %s\n", name, settings[name].get(s), settings[name].description)
+		}
+	case 1:
+		opt, ok := settings[args[0]]
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Unknown setting:", args[0])
+			return
+		}
+		fmt.Printf("%s = %s\n", args[0], opt.get(s))
+	default:
+		if _, ok := settings[args[0]]; !ok {
+			fmt.Fprintln(os.Stderr, "Unknown setting:", args[0])
+			return
+		}
+		s.setSetting(args[0], strings.Join(args[1:], " "))
+	}
+}
+
+// setSetting changes a setting and reports whether the value was accepted
+func (s *PromptSession) setSetting(name, value string) bool {
+	if err := settings[name].set(s, value); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid value for %s: %v\n", name, err)
+		return false
+	}
+	return true
+}
+
+func (s *PromptSession) runColWidth(args []string) {
+	switch len(args) {
+	case 0:
+		names := make([]string, 0, len(s.display.ColumnWidths))
+		for name := range s.display.ColumnWidths {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %-20s %d\n", quoteIdentifier(name), s.display.ColumnWidths[name])
+		}
+	case 1:
+		name := columnName(args[0])
+		if width, ok := s.display.ColumnWidths[name]; ok {
+			fmt.Printf("%s = %d\n", quoteIdentifier(name), width)
+		} else {
+			fmt.Printf("%s = %s\n", quoteIdentifier(name), settings["max_col_width"].get(s))
+		}
+	default:
+		var width int
+		if err := parseCount(args[1], &width); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid width for %s: %v\n", args[0], err)
+			return
+		}
+		// Copy before changing so results already handed out keep their widths
+		widths := make(map[string]int, len(s.display.ColumnWidths)+1)
+		for name, w := range s.display.ColumnWidths {
+			widths[name] = w
+		}
+		if name := columnName(args[0]); width == 0 {
+			delete(widths, name)
+		} else {
+			widths[name] = width
+		}
+		s.display.ColumnWidths = widths
+	}
+}
+
+// settingCommand returns a command that shows or changes a single setting,
+// for settings important enough to deserve their own backslash command
+func settingCommand(name string) func(s *PromptSession, args []string) {
+	return func(s *PromptSession, args []string) {
+		s.runSet(append([]string{name}, args...))
+	}
+}
+
+// columnName returns the name of the column an identifier refers to: the
+// text of a quoted identifier, or an unquoted one in upper case
+func columnName(ident string) string {
+	if len(ident) >= 2 && ident[0] == '"' && ident[len(ident)-1] == '"' {
+		return unquote(ident)
+	}
+	return strings.ToUpper(ident)
+}
+
+// unquote removes the single or double quotes around value, so that
+// \null "" can set an empty text. Quotes inside are doubled, as in SQL.
+func unquote(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+	q := value[0]
+	if (q != '"' && q != '\'') || value[len(value)-1] != q {
+		return value
+	}
+	return strings.ReplaceAll(value[1:len(value)-1], string([]byte{q, q}), string(q))
+}
+
+// parseBool accepts the on/off spellings commonly used by SQL shells
+func parseBool(value string, dst *bool) error {
+	switch strings.ToLower(value) {
+	case "on", "true", "yes", "1":
+		*dst = true
+	case "off", "false", "no", "0":
+		*dst = false
+	default:
+		return fmt.Errorf("expected on or off, got %q", value)
+	}
+	return nil
+}
+
+// parseChoice accepts one of choices, ignoring case
+func parseChoice(value string, choices []string, dst *string) error {
+	for _, c := range choices {
+		if strings.EqualFold(value, c) {
+			*dst = c
+			return nil
+		}
+	}
+	return fmt.Errorf("expected one of %s, got %q", strings.Join(choices, ", "), value)
+}
+
+// parseCount accepts a non-negative integer
+func parseCount(value string, dst *int) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return fmt.Errorf("expected a non-negative number, got %q", value)
+	}
+	*dst = n
+	return nil
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
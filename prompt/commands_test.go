@@ -0,0 +1,181 @@
+package prompt
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRunSet(t *testing.T) {
+	session := &PromptSession{}
+
+	session.runMetaCommand(`\set show_connection_id on`)
+	if !session.showConnectionID {
+		t.Error("Expected show_connection_id to be enabled")
+	}
+
+	session.runMetaCommand(`\set show_connection_id maybe`)
+	if !session.showConnectionID {
+		t.Error("Expected an invalid value to leave the setting unchanged")
+	}
+
+	session.runMetaCommand(`\set show_connection_id off`)
+	if session.showConnectionID {
+		t.Error("Expected show_connection_id to be disabled")
+	}
+
+	session.runMetaCommand(`\set timezone UTC`)
+	if session.display.TimeZone != time.UTC {
+		t.Errorf("Expected timezone UTC, got %v", session.display.TimeZone)
+	}
+
+	session.runMetaCommand(`\set timezone Nowhere/Else`)
+	if session.display.TimeZone != time.UTC {
+		t.Error("Expected an unknown zone to leave the timezone unchanged")
+	}
+
+	session.runMetaCommand(`\set timezone default`)
+	if session.display.TimeZone != nil {
+		t.Error("Expected default to restore the driver's zone")
+	}
+}
+
+func TestRunNull(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`\null ""`, ""},
+		{`\null ''`, ""},
+		{`\null 'it''s'`, "it's"},
+		{`\null (null)`, "(null)"},
+		{`\null "unclosed`, `"unclosed`},
+	}
+	for _, tt := range tests {
+		session := &PromptSession{}
+		if got := settings["null"].get(session); got != "NULL" {
+			t.Errorf("Expected NULL before the text is set, got %q", got)
+		}
+		session.runMetaCommand(tt.line)
+		if session.display.NullString == nil || *session.display.NullString != tt.want {
+			t.Errorf("%s: expected NULL text %q, got %v", tt.line, tt.want, session.display.NullString)
+		}
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{value: "on", want: true},
+		{value: "TRUE", want: true},
+		{value: "off", want: false},
+		{value: "0", want: false},
+		{value: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var got bool
+		err := parseBool(tt.value, &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBool(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseBool(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRunLimit(t *testing.T) {
+	session := &PromptSession{}
+
+	session.runMetaCommand(`\limit 50`)
+	if session.display.MaxRows != 50 {
+		t.Errorf("Expected max rows 50, got %d", session.display.MaxRows)
+	}
+
+	session.runMetaCommand(`\limit -1`)
+	if session.display.MaxRows != 50 {
+		t.Errorf("Expected a negative limit to be rejected, got %d", session.display.MaxRows)
+	}
+
+	session.runMetaCommand(`\limit 0`)
+	if session.display.MaxRows != 0 {
+		t.Errorf("Expected the limit to be disabled, got %d", session.display.MaxRows)
+	}
+}
+
+func TestRunFormat(t *testing.T) {
+	session := &PromptSession{}
+
+	session.runMetaCommand(`\format CSV`)
+	if session.display.Format != "csv" {
+		t.Errorf("Expected csv format, got %q", session.display.Format)
+	}
+
+	session.runMetaCommand(`\format xml`)
+	if session.display.Format != "csv" {
+		t.Errorf("Expected an unknown format to be rejected, got %q", session.display.Format)
+	}
+}
+
+func TestRunColWidth(t *testing.T) {
+	session := &PromptSession{}
+
+	session.runMetaCommand(`\colwidth description 40`)
+	if got := session.display.ColumnWidths["DESCRIPTION"]; got != 40 {
+		t.Errorf("Expected DESCRIPTION width 40, got %d", got)
+	}
+
+	session.runMetaCommand(`\colwidth description wide`)
+	if got := session.display.ColumnWidths["DESCRIPTION"]; got != 40 {
+		t.Error("Expected an invalid width to leave the override unchanged")
+	}
+
+	session.runMetaCommand(`\colwidth "description" 20`)
+	if got := session.display.ColumnWidths["description"]; got != 20 || session.display.ColumnWidths["DESCRIPTION"] != 40 {
+		t.Errorf("Expected a quoted name to keep its case, got %v", session.display.ColumnWidths)
+	}
+
+	session.runMetaCommand(`\colwidth DESCRIPTION 0`)
+	if _, ok := session.display.ColumnWidths["DESCRIPTION"]; ok {
+		t.Error("Expected width 0 to remove the override")
+	}
+}
+
+func TestRunTiming(t *testing.T) {
+	session := &PromptSession{}
+
+	session.runMetaCommand(`\timing`)
+	if !session.display.Timing {
+		t.Error("Expected \\timing to turn timing on")
+	}
+	session.runMetaCommand(`\timing`)
+	if session.display.Timing {
+		t.Error("Expected a second \\timing to turn timing off")
+	}
+	session.runMetaCommand(`\timing on`)
+	if !session.display.Timing {
+		t.Error("Expected \\timing on to turn timing on")
+	}
+
+	// An invalid value changes nothing and does not report a state
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Unexpected error creating pipe: %s", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	session.runMetaCommand(`\timing maybe`)
+	session.runMetaCommand(`\x sideways`)
+	os.Stdout = stdout
+	w.Close()
+	out, _ := io.ReadAll(r)
+	if !session.display.Timing || session.display.Expanded != "" || len(out) != 0 {
+		t.Errorf("Expected invalid values to be rejected without output, got %q", out)
+	}
+}
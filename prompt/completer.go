@@ -681,7 +681,6 @@ var sqlSuggestions = []prompt.Suggest{
 	{Text: "UNPIVOT", Description: "Transforms columns into rows in a result set."},
 	{Text: "UPDATE", Description: "Modifies data in a database table."},
 	{Text: "UPPER", Description: "Converts a string to uppercase."},
-	{Text: "UPSERT", Description: "Performs an update or insert if a row exists or not."},
 	{Text: "USAGE", Description: "Specifies usage or access permissions."},
 	{Text: "USER", Description: "Represents a user or account."},
 	{Text: "USER_DEFINED_TYPE_CATALOG", Description: "Returns the catalog of a user-defined type."},
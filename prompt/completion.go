@@ -0,0 +1,366 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+	calcitesql "github.com/satyakommula96/calcite-cli/calcitesql"
+)
+
+// completionWordSeparator ends the word replaced by a completion, so that
+// completing b in "a,b", "COUNT(b" or "o.b" keeps the text before it
+const completionWordSeparator = " ,;()=<>+-*/|.'\"\t\n"
+
+// clause is the part of a statement the cursor is in, deciding what is
+// suggested there
+type clause int
+
+const (
+	clauseKeyword clause = iota
+	clauseTable
+	clauseColumn
+)
+
+// tableKeywords are followed by a table name
+var tableKeywords = map[string]bool{
+	"FROM": true, "JOIN": true, "INTO": true, "UPDATE": true, "TABLE": true,
+}
+
+// columnKeywords start a clause made of column expressions
+var columnKeywords = map[string]bool{
+	"SELECT": true, "WHERE": true, "ON": true, "BY": true, "HAVING": true, "SET": true,
+}
+
+// aliasStopWords may follow a table name and are never taken as its alias
+var aliasStopWords = map[string]bool{
+	"WHERE": true, "JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true,
+	"FULL": true, "OUTER": true, "CROSS": true, "NATURAL": true, "ON": true,
+	"USING": true, "GROUP": true, "ORDER": true, "HAVING": true, "LIMIT": true,
+	"OFFSET": true, "FETCH": true, "UNION": true, "EXCEPT": true, "INTERSECT": true,
+	"MINUS": true, "WINDOW": true, "SET": true, "VALUES": true, "SELECT": true,
+	"AS": true, "FOR": true, "LATERAL": true, "TABLESAMPLE": true,
+}
+
+// completionContext describes the text before the cursor
+type completionContext struct {
+	// word is the partially typed word before the cursor
+	word string
+	// qualifier holds the dotted names before word, as in o. or SALES.
+	qualifier []string
+	clause    clause
+	// quoted is set when word follows an opening double quote
+	quoted bool
+}
+
+// tableRef is a table named in a statement, with its alias if any
+type tableRef struct {
+	parts []string
+	alias string
+}
+
+// identifier returns the name written by a word or quoted identifier token
+func identifier(tok calcitesql.Token) (string, bool) {
+	switch tok.Kind {
+	case calcitesql.TokenWord:
+		return tok.Text, true
+	case calcitesql.TokenQuotedIdent:
+		if tok.Unterminated || len(tok.Text) < 2 {
+			return "", false
+		}
+		q := tok.Text[:1]
+		return strings.ReplaceAll(tok.Text[1:len(tok.Text)-1], q+q, q), true
+	}
+	return "", false
+}
+
+func isPunct(tok calcitesql.Token, p string) bool {
+	return tok.Kind == calcitesql.TokenPunct && tok.Text == p
+}
+
+// currentStatement returns the tokens of the statement around the cursor,
+// given the text before and after it
+func currentStatement(before, after string) []calcitesql.Token {
+	var tokens []calcitesql.Token
+	for _, tok := range calcitesql.SignificantTokens(before) {
+		if isPunct(tok, ";") {
+			tokens = tokens[:0]
+			continue
+		}
+		tokens = append(tokens, tok)
+	}
+	for _, tok := range calcitesql.SignificantTokens(after) {
+		if isPunct(tok, ";") {
+			break
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+// analyzeCompletion tokenizes the statement up to the cursor and works out
+// which clause the word being typed belongs to
+func analyzeCompletion(text string) completionContext {
+	tokens := calcitesql.Tokenize(text)
+	var ctx completionContext
+
+	// The word being typed is the last token if nothing follows it
+	if n := len(tokens); n > 0 {
+		last := tokens[n-1]
+		switch last.Kind {
+		case calcitesql.TokenWord:
+			ctx.word = last.Text
+			tokens = tokens[:n-1]
+		case calcitesql.TokenQuotedIdent:
+			if last.Unterminated && strings.HasPrefix(last.Text, `"`) {
+				// A name is being typed in quotes, so keep its case
+				ctx.word = strings.ReplaceAll(last.Text[1:], `""`, `"`)
+				ctx.quoted = true
+				tokens = tokens[:n-1]
+			} else if last.Unterminated {
+				return completionContext{}
+			}
+		case calcitesql.TokenString, calcitesql.TokenComment:
+			if last.Unterminated {
+				// Nothing to complete inside a string or comment
+				return completionContext{}
+			}
+		}
+	}
+
+	// Only the current statement matters
+	var significant []calcitesql.Token
+	for _, tok := range tokens {
+		switch {
+		case isPunct(tok, ";"):
+			significant = significant[:0]
+		case tok.Kind != calcitesql.TokenWhitespace && tok.Kind != calcitesql.TokenComment:
+			significant = append(significant, tok)
+		}
+	}
+
+	// A qualifier is a chain of names each directly followed by a dot
+	end := len(tokens)
+	for end >= 2 && isPunct(tokens[end-1], ".") {
+		name, ok := identifier(tokens[end-2])
+		if !ok {
+			break
+		}
+		ctx.qualifier = append([]string{name}, ctx.qualifier...)
+		end -= 2
+		significant = significant[:len(significant)-2]
+	}
+
+	// Walk back to the nearest clause keyword at the cursor's nesting level,
+	// skipping over complete parenthesized expressions such as subqueries
+	depth := 0
+	for i := len(significant) - 1; i >= 0; i-- {
+		tok := significant[i]
+		switch {
+		case isPunct(tok, ")"):
+			depth++
+		case isPunct(tok, "("):
+			if depth > 0 {
+				depth--
+			}
+		case depth > 0 || tok.Kind != calcitesql.TokenWord:
+		case tableKeywords[tok.Upper()]:
+			// A table name directly follows the keyword or a comma in a
+			// FROM list; after the name come aliases and keywords
+			if i == len(significant)-1 || isPunct(significant[len(significant)-1], ",") {
+				ctx.clause = clauseTable
+			}
+			return ctx
+		case columnKeywords[tok.Upper()]:
+			ctx.clause = clauseColumn
+			return ctx
+		}
+	}
+	return ctx
+}
+
+// tableReferences finds the tables named after FROM, JOIN, UPDATE and INTO
+// in a statement, including comma separated FROM lists, along with their
+// aliases
+func tableReferences(tokens []calcitesql.Token) []tableRef {
+	var refs []tableRef
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].Kind != calcitesql.TokenWord || !tableKeywords[tokens[i].Upper()] {
+			continue
+		}
+		fromList := tokens[i].IsWord("FROM")
+		for {
+			ref, next := parseTableRef(tokens, i+1)
+			if len(ref.parts) > 0 {
+				refs = append(refs, ref)
+			}
+			i = next - 1
+			if !fromList || next >= len(tokens) || !isPunct(tokens[next], ",") {
+				break
+			}
+			i = next
+		}
+	}
+	return refs
+}
+
+// parseTableRef reads a dotted table name and optional alias starting at
+// tokens[i], returning the index of the first token after it
+func parseTableRef(tokens []calcitesql.Token, i int) (tableRef, int) {
+	var ref tableRef
+	for i < len(tokens) {
+		name, ok := identifier(tokens[i])
+		if !ok {
+			break
+		}
+		ref.parts = append(ref.parts, name)
+		i++
+		if i >= len(tokens) || !isPunct(tokens[i], ".") {
+			break
+		}
+		i++
+	}
+	if len(ref.parts) == 0 {
+		return ref, i
+	}
+
+	if i < len(tokens) && tokens[i].IsWord("AS") {
+		i++
+	}
+	if i < len(tokens) && !aliasStopWords[tokens[i].Upper()] {
+		if alias, ok := identifier(tokens[i]); ok {
+			ref.alias = alias
+			i++
+		}
+	}
+	return ref, i
+}
+
+func (s *PromptSession) completer(d prompt.Document) []prompt.Suggest {
+	before := d.TextBeforeCursor()
+	if s.isMultiline {
+		// Earlier lines of the statement give the context for this one
+		before = s.multiLineQuery.String() + before
+	}
+	ctx := analyzeCompletion(before)
+	if ctx.word == "" && len(ctx.qualifier) == 0 && !ctx.quoted {
+		return nil
+	}
+	// Aliases are usually declared after the cursor, in the FROM clause
+	refs := tableReferences(currentStatement(before, d.TextAfterCursor()))
+	meta := s.catalog.snapshot()
+
+	var names, keywords []prompt.Suggest
+	switch {
+	case len(ctx.qualifier) > 0:
+		names = s.qualifiedSuggestions(meta, ctx.qualifier, refs)
+	case ctx.clause == clauseTable && meta != nil:
+		names = append(tableSuggestions(meta.tables), schemaSuggestions(meta.schemas())...)
+	case ctx.clause == clauseColumn:
+		// Columns first, then functions and keywords such as FROM or AND
+		names, keywords = s.referencedColumns(meta, refs), s.sqlKeywords(meta)
+	default:
+		keywords = s.sqlKeywords(meta)
+	}
+	if keywords != nil {
+		keywords = append(keywords[:len(keywords):len(keywords)], serverFunctionSuggestions(meta, keywords)...)
+	}
+
+	style := insertStyle{quoteNames: true, keywordCase: s.keywordCase}
+	fuzzy := s.match != MatchPrefix
+	if ctx.quoted {
+		// Only names can be quoted, and the replaced text must be the end
+		// of the name
+		style = insertStyle{inQuotes: true, typed: ctx.word}
+		keywords = nil
+		fuzzy = false
+	}
+	return rankSuggestions(names, keywords, ctx.word, fuzzy, style)
+}
+
+// qualifiedSuggestions completes the name after "qualifier.": the columns of
+// an alias or table, or the tables of a schema
+func (s *PromptSession) qualifiedSuggestions(meta *metadata, qualifier []string, refs []tableRef) []prompt.Suggest {
+	if len(qualifier) == 1 {
+		for _, ref := range refs {
+			if strings.EqualFold(ref.alias, qualifier[0]) {
+				return s.tableColumns(meta.lookupTable(ref.parts))
+			}
+		}
+	}
+	if tables := meta.lookupTable(qualifier); len(tables) > 0 {
+		return s.tableColumns(tables)
+	}
+	if len(qualifier) == 1 {
+		return tableSuggestions(meta.tablesIn(qualifier[0]))
+	}
+	return nil
+}
+
+// referencedColumns returns the columns of the tables used by the statement,
+// or the columns loaded so far if none of them is known
+func (s *PromptSession) referencedColumns(meta *metadata, refs []tableRef) []prompt.Suggest {
+	var tables []*tableInfo
+	for _, ref := range refs {
+		tables = append(tables, meta.lookupTable(ref.parts)...)
+	}
+	if len(tables) == 0 && meta != nil {
+		return columnSuggestions(meta.tables)
+	}
+	return s.tableColumns(tables)
+}
+
+// tableColumns suggests the columns of tables, loading those of their
+// schemas in the background when first referenced. They are offered once
+// loaded, on a later key press.
+func (s *PromptSession) tableColumns(tables []*tableInfo) []prompt.Suggest {
+	s.catalog.loadColumns(tables)
+	return columnSuggestions(tables)
+}
+
+func tableSuggestions(tables []*tableInfo) []prompt.Suggest {
+	suggestions := make([]prompt.Suggest, 0, len(tables))
+	for _, t := range tables {
+		suggestions = append(suggestions, prompt.Suggest{Text: t.name, Description: "Table Name"})
+	}
+	return suggestions
+}
+
+func schemaSuggestions(schemas []string) []prompt.Suggest {
+	suggestions := make([]prompt.Suggest, 0, len(schemas))
+	for _, schema := range schemas {
+		suggestions = append(suggestions, prompt.Suggest{Text: schema, Description: "Schema Name"})
+	}
+	return suggestions
+}
+
+// columnSuggestions lists the columns of tables, each name once
+func columnSuggestions(tables []*tableInfo) []prompt.Suggest {
+	var suggestions []prompt.Suggest
+	seen := map[string]bool{}
+	for _, t := range tables {
+		for _, c := range t.columns {
+			if !seen[c.name] {
+				seen[c.name] = true
+				suggestions = append(suggestions, prompt.Suggest{Text: c.name, Description: "Column Name"})
+			}
+		}
+	}
+	return suggestions
+}
@@ -0,0 +1,78 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	calcitesql "github.com/satyakommula96/calcite-cli/calcitesql"
+)
+
+// confirmDestructive asks the user to confirm a destructive statement by
+// typing yes or the name of the affected table. It returns true when the
+// statement may run.
+func (s *PromptSession) confirmDestructive(st calcitesql.Statement, query string) bool {
+	if !s.confirm || st.Destructive == "" {
+		return true
+	}
+
+	fmt.Printf("About to run %s", st.Destructive)
+	if st.Table != "" {
+		fmt.Printf(" on %s", st.Table)
+	}
+	fmt.Printf(":\n  %s\n", strings.TrimSpace(query))
+	if st.Table != "" {
+		fmt.Printf("Type 'yes' or %s to continue: ", st.Table)
+	} else {
+		fmt.Print("Type 'yes' to continue: ")
+	}
+
+	answer := strings.TrimSpace(s.readLine())
+	if strings.EqualFold(answer, "yes") || (st.Table != "" && answer == st.Table) {
+		return true
+	}
+	fmt.Println("Cancelled")
+	return false
+}
+
+// readLine reads one line from the confirmation input. It reads a byte at a
+// time so nothing typed after the line is taken away from go-prompt.
+func (s *PromptSession) readLine() string {
+	in := s.confirmIn
+	if in == nil {
+		in = os.Stdin
+	}
+	var line strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line.WriteByte(buf[0])
+		}
+		if err == io.EOF || (err != nil && n == 0) {
+			break
+		}
+	}
+	return strings.TrimRight(line.String(), "\r")
+}
@@ -0,0 +1,33 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPromptSessionConfirmDestructive(t *testing.T) {
+	session, _, mock := newMockSession(t)
+	session.confirm = true
+
+	// The first DELETE is cancelled, the second is confirmed with the table name
+	// and the DROP with yes
+	session.confirmIn = strings.NewReader("no\nORDERS\nYES\n")
+	mock.ExpectQuery("DELETE FROM ORDERS").WillReturnRows(sqlmock.NewRows([]string{"ROWS"}))
+	mock.ExpectQuery("DROP TABLE T").WillReturnRows(sqlmock.NewRows([]string{"ROWS"}))
+	mock.ExpectQuery("DELETE FROM T WHERE ID = 1").WillReturnRows(sqlmock.NewRows([]string{"ROWS"}))
+
+	session.executor("DELETE FROM ORDERS;")
+	session.executor("DELETE FROM ORDERS;")
+	session.executor("DROP TABLE T;")
+	session.executor("DELETE FROM T WHERE ID = 1;")
+
+	session.confirm = false
+	mock.ExpectQuery("DROP TABLE U").WillReturnRows(sqlmock.NewRows([]string{"ROWS"}))
+	session.executor("DROP TABLE U;")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// avaticaPackage is the package of the avatica-go driver connection
+const avaticaPackage = "github.com/apache/calcite-avatica-go/v5"
+
+// connect pins the session to a single Avatica connection, so that session
+// state such as the default schema or an open transaction survives between
+// statements.
+func (s *PromptSession) connect() error {
+	conn, err := s.db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	if s.connID, err = avaticaConnectionID(conn); err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading connection ID:", err)
+	}
+	if err := s.syncConnection(); err != nil {
+		fmt.Fprintln(os.Stderr, "WARNING: read-only mode and isolation level were not synced with the server:", err)
+	}
+	if s.showConnectionID {
+		fmt.Println("Connection ID:", s.connectionID())
+	}
+	return nil
+}
+
+// syncConnection applies the read-only flag and isolation level of the
+// session to the pinned connection. The DSN and connection properties already
+// ask for them when the connection opens, but not every server honours that,
+// and database/sql has no API for either, so they are sent again with an
+// Avatica ConnectionSyncRequest.
+func (s *PromptSession) syncConnection() error {
+	if !s.readOnly && s.isolation == sql.LevelDefault {
+		return nil
+	}
+	if s.rpc == nil {
+		return errors.New("read-only mode and isolation levels need the Avatica protobuf protocol")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	return s.rpc.syncConnection(ctx, s.connectionID(), s.readOnly, jdbcIsolation[s.isolation])
+}
+
+// reconnect drops the pinned connection, including any open transaction,
+// and acquires a fresh one from the pool.
+func (s *PromptSession) reconnect() error {
+	if s.tx != nil {
+		fmt.Fprintln(os.Stderr, "WARNING: transaction was lost with the connection")
+		s.tx = nil
+	}
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+		s.connID = ""
+	}
+	return s.connect()
+}
+
+// checkConnection re-acquires the pinned connection if err shows that the
+// server no longer knows it, for example after the Avatica connection expired.
+func (s *PromptSession) checkConnection(err error) {
+	if !errors.Is(err, driver.ErrBadConn) && !errors.Is(err, sql.ErrConnDone) {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Connection lost, reconnecting. Session state has been reset.")
+	if err := s.reconnect(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error reconnecting:", err)
+	}
+}
+
+// connectionID returns the Avatica connection ID of the pinned connection,
+// read once when it was acquired
+func (s *PromptSession) connectionID() string {
+	return s.connID
+}
+
+// avaticaConnectionID reads the Avatica connection ID of conn. The driver does
+// not export it, so it is read from the driver connection, and an error
+// reports a driver that no longer has the field. Connections of other
+// drivers have no ID.
+func avaticaConnectionID(conn *sql.Conn) (string, error) {
+	var id string
+	err := conn.Raw(func(driverConn interface{}) error {
+		v := reflect.Indirect(reflect.ValueOf(driverConn))
+		if v.Kind() != reflect.Struct || v.Type().PkgPath() != avaticaPackage {
+			return nil
+		}
+		f := v.FieldByName("connectionId")
+		if !f.IsValid() || f.Kind() != reflect.String {
+			return fmt.Errorf("%s has no connectionId field", v.Type())
+		}
+		id = f.String()
+		return nil
+	})
+	return id, err
+}
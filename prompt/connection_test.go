@@ -0,0 +1,102 @@
+package prompt
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	avatica "github.com/apache/calcite-avatica-go/v5"
+)
+
+func TestPromptSessionReconnect(t *testing.T) {
+	session, db, mock := newMockSession(t)
+
+	// sqlmock forgets the stub connection once every handle on it is closed,
+	// so keep one open to let the session reconnect
+	spare, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error opening connection: %s", err)
+	}
+	defer spare.Close()
+	first := session.conn
+
+	mock.ExpectQuery("SELECT 1").WillReturnError(driver.ErrBadConn)
+	mock.ExpectQuery("SELECT 2").WillReturnRows(sqlmock.NewRows([]string{"X"}).AddRow(2))
+
+	session.executor("SELECT 1;")
+	if session.conn == first {
+		t.Error("Expected a new connection after the server dropped the old one")
+	}
+	session.executor("SELECT 2;")
+	session.conn.Close()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPromptSessionSyncConnection(t *testing.T) {
+	fake := &fakeAvatica{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	rpc, err := newAvaticaClient(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error creating client: %s", err)
+	}
+
+	session, _, _ := newMockSession(t)
+	if err := session.syncConnection(); err != nil || len(fake.synced) != 0 {
+		t.Fatalf("Expected nothing to sync by default, got %v and %d requests", err, len(fake.synced))
+	}
+
+	session.rpc = rpc
+	session.readOnly = true
+	if err := session.syncConnection(); err != nil {
+		t.Fatalf("Unexpected error syncing connection: %s", err)
+	}
+	session.runMetaCommand(`\isolation serializable`)
+	if len(fake.synced) != 2 {
+		t.Fatalf("Expected 2 ConnectionSyncRequests, got %d", len(fake.synced))
+	}
+	if props := fake.synced[0]; !props.GetReadOnly() || !props.GetHasReadOnly() || props.GetTransactionIsolation() != 0 {
+		t.Errorf("Expected a read-only connection with its isolation unchanged, got %v", props)
+	}
+	if props := fake.synced[1]; !props.GetReadOnly() || props.GetTransactionIsolation() != 8 {
+		t.Errorf("Expected serializable isolation, got %v", props)
+	}
+
+	// Without the protocol the level is still used by BEGIN
+	session.rpc = nil
+	session.runMetaCommand(`\isolation read-committed`)
+	if session.isolation != sql.LevelReadCommitted {
+		t.Errorf("Expected read-committed isolation, got %v", session.isolation)
+	}
+	session.reconnect()
+	if session.conn == nil {
+		t.Error("Expected the connection to open when it cannot be synced")
+	}
+}
+
+func TestAvaticaConnectionID(t *testing.T) {
+	fake := &fakeAvatica{props: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	db := sql.OpenDB(avatica.NewConnector(server.URL))
+	defer db.Close()
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error opening connection: %s", err)
+	}
+	defer conn.Close()
+	id, err := avaticaConnectionID(conn)
+	if err != nil {
+		t.Fatalf("Expected avatica-go to keep the connection ID in connectionId: %s", err)
+	}
+	if len(fake.opened) != 1 || id != fake.opened[0] {
+		t.Errorf("Expected connection ID %v, got %q", fake.opened, id)
+	}
+}
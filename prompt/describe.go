@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	calcitesql "github.com/satyakommula96/calcite-cli/calcitesql"
+)
+
+// qualifiedName joins the non-empty parts of a table's name with dots
+func (t *tableInfo) qualifiedName() string {
+	var parts []string
+	for _, p := range []string{t.catalog, t.schema, t.name} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// printListing writes metadata rows in the session's output format
+func (s *PromptSession) printListing(w io.Writer, names []string, rows [][]interface{}) error {
+	display := s.display
+	if width, _, ok := terminalSize(); ok && w == os.Stdout {
+		display.TermWidth = width
+	}
+	f, err := calcitesql.NewFormatter(w, display)
+	if err != nil {
+		return err
+	}
+	columns := make([]calcitesql.Column, len(names))
+	for i, name := range names {
+		columns[i] = calcitesql.Column{Name: name}
+	}
+	if err := f.Header(columns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := f.Row(row); err != nil {
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// describe lists the tables and views when name is empty, otherwise the
+// columns of the tables it refers to
+func (s *PromptSession) describe(w io.Writer, name string) error {
+	// Wait for metadata still loading in the background
+	s.catalog.wait()
+	meta := s.catalog.snapshot()
+	if name == "" {
+		var rows [][]interface{}
+		if meta != nil {
+			for _, t := range meta.tables {
+				rows = append(rows, []interface{}{t.schema, t.name, t.kind})
+			}
+		}
+		if len(rows) == 0 {
+			return fmt.Errorf("no tables found")
+		}
+		return s.printListing(w, []string{"Schema", "Name", "Type"}, rows)
+	}
+
+	ref, _ := parseTableRef(calcitesql.SignificantTokens(name), 0)
+	tables := meta.lookupTable(ref.parts)
+	if len(tables) == 0 {
+		return fmt.Errorf("no table named %s", name)
+	}
+	s.catalog.loadColumns(tables)
+	s.catalog.wait()
+	tables = s.catalog.snapshot().lookupTable(ref.parts)
+	for i, t := range tables {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		kind := t.kind
+		if kind == "" {
+			kind = "TABLE"
+		}
+		fmt.Fprintf(w, "%s %s\n", kind, t.qualifiedName())
+		var rows [][]interface{}
+		for _, c := range t.columns {
+			rows = append(rows, []interface{}{c.name, c.typeName, c.nullable})
+		}
+		if err := s.printListing(w, []string{"Column", "Type", "Nullable"}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describeSchemas lists the schemas with the catalog each belongs to
+func (s *PromptSession) describeSchemas(w io.Writer) error {
+	s.catalog.wait()
+	meta := s.catalog.snapshot()
+	var rows [][]interface{}
+	if meta != nil {
+		for _, schema := range meta.schemaList {
+			rows = append(rows, []interface{}{schema.catalog, schema.name})
+		}
+		// Schemas only known from their tables
+		listed := map[string]bool{}
+		for _, schema := range meta.schemaList {
+			listed[schema.name] = true
+		}
+		for _, t := range meta.tables {
+			if t.schema != "" && !listed[t.schema] {
+				listed[t.schema] = true
+				rows = append(rows, []interface{}{t.catalog, t.schema})
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("no schemas found")
+	}
+	return s.printListing(w, []string{"Catalog", "Schema"}, rows)
+}
@@ -0,0 +1,50 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	session := &PromptSession{catalog: newMetadataCache(nil)}
+	session.display.Format = "csv"
+	meta := session.catalog.snapshot()
+	meta.addSchema("", "EMPTY")
+	orders := meta.addTable("", "SALES", "ORDERS")
+	orders.kind = "TABLE"
+	orders.columns = []columnInfo{{"ID", "INTEGER", "NO"}, {"NOTE", "VARCHAR", "YES"}}
+	meta.addTable("", "SALES", "BIG ORDERS").kind = "VIEW"
+
+	tests := []struct {
+		name    string
+		arg     string
+		want    string
+		wantErr bool
+	}{
+		{name: "tables", want: "Schema,Name,Type\nSALES,ORDERS,TABLE\nSALES,BIG ORDERS,VIEW\n"},
+		{name: "table", arg: "orders", want: "TABLE SALES.ORDERS\nColumn,Type,Nullable\nID,INTEGER,NO\nNOTE,VARCHAR,YES\n"},
+		{name: "quoted", arg: `SALES."BIG ORDERS"`, want: "VIEW SALES.BIG ORDERS\nColumn,Type,Nullable\n"},
+		{name: "missing", arg: "MISSING", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := session.describe(&out, tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("describe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("describe(%q) = %q, want %q", tt.arg, got, tt.want)
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	if err := session.describeSchemas(&out); err != nil {
+		t.Fatalf("Unexpected error listing schemas: %s", err)
+	}
+	if got := out.String(); !strings.Contains(got, ",EMPTY\n") || !strings.Contains(got, ",SALES\n") {
+		t.Errorf("Expected schemas with and without tables, got %q", got)
+	}
+}
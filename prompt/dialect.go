@@ -0,0 +1,178 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+)
+
+// SQL dialects completion offers keywords and functions for
+const (
+	DialectAuto    = "auto"
+	DialectCalcite = "calcite"
+	DialectPhoenix = "phoenix"
+	DialectDruid   = "druid"
+	DialectHive    = "hive"
+)
+
+// Dialects lists the accepted dialects
+var Dialects = []string{DialectAuto, DialectCalcite, DialectPhoenix, DialectDruid, DialectHive}
+
+// dialectExtensions are the keywords and functions each dialect adds to
+// those of Calcite in sqlSuggestions. Functions are described by their
+// signature.
+var dialectExtensions = map[string][]prompt.Suggest{
+	DialectPhoenix: {
+		{Text: "UPSERT", Description: "Inserts a row or updates the row with the same primary key."},
+		{Text: "DUPLICATE", Description: "Used in ON DUPLICATE KEY to update a row that already exists."},
+		{Text: "SALT_BUCKETS", Description: "Table option spreading rows over a number of salted regions."},
+		{Text: "IMMUTABLE_ROWS", Description: "Table option for rows that are written once and never updated."},
+		{Text: "COLUMN_ENCODED_BYTES", Description: "Table option setting how column names are encoded."},
+		{Text: "DISABLE_WAL", Description: "Table option skipping the HBase write-ahead log."},
+		{Text: "MULTI_TENANT", Description: "Table option scoping rows by the leading tenant column."},
+		{Text: "TTL", Description: "Table option setting how long cells are kept."},
+		{Text: "SPLIT", Description: "Used in SPLIT ON to give the initial region split points."},
+		{Text: "ASYNC", Description: "Builds an index in the background with a MapReduce job."},
+		{Text: "REBUILD", Description: "Rebuilds an index with ALTER INDEX."},
+		{Text: "STATISTICS", Description: "Used in UPDATE STATISTICS to refresh table statistics."},
+		{Text: "ARRAY_APPEND", Description: "ARRAY_APPEND(array, element)"},
+		{Text: "ARRAY_CAT", Description: "ARRAY_CAT(array1, array2)"},
+		{Text: "ARRAY_LENGTH", Description: "ARRAY_LENGTH(array)"},
+		{Text: "ARRAY_TO_STRING", Description: "ARRAY_TO_STRING(array, delimiter [, nullString])"},
+		{Text: "CONVERT_TZ", Description: "CONVERT_TZ(date, fromTimeZone, toTimeZone)"},
+		{Text: "FIRST_VALUES", Description: "FIRST_VALUES(value, n) WITHIN GROUP (ORDER BY expression)"},
+		{Text: "INSTR", Description: "INSTR(string, search)"},
+		{Text: "MD5", Description: "MD5(value)"},
+		{Text: "REGEXP_REPLACE", Description: "REGEXP_REPLACE(string, pattern [, replacement])"},
+		{Text: "REGEXP_SPLIT", Description: "REGEXP_SPLIT(string, pattern)"},
+		{Text: "REGEXP_SUBSTR", Description: "REGEXP_SUBSTR(string, pattern [, start])"},
+		{Text: "TO_CHAR", Description: "TO_CHAR(timestamp | number [, format])"},
+		{Text: "TO_DATE", Description: "TO_DATE(string [, format [, timeZone]])"},
+		{Text: "TO_NUMBER", Description: "TO_NUMBER(string | timestamp [, format])"},
+		{Text: "TO_TIMESTAMP", Description: "TO_TIMESTAMP(string [, format [, timeZone]])"},
+	},
+	DialectDruid: {
+		{Text: "APPROX_COUNT_DISTINCT", Description: "APPROX_COUNT_DISTINCT(expression)"},
+		{Text: "APPROX_QUANTILE", Description: "APPROX_QUANTILE(expression, probability [, resolution])"},
+		{Text: "DS_HLL", Description: "DS_HLL(expression [, lgK [, tgtHllType]])"},
+		{Text: "DS_THETA", Description: "DS_THETA(expression [, size])"},
+		{Text: "EARLIEST", Description: "EARLIEST(expression [, maxBytesPerString])"},
+		{Text: "LATEST", Description: "LATEST(expression [, maxBytesPerString])"},
+		{Text: "MILLIS_TO_TIMESTAMP", Description: "MILLIS_TO_TIMESTAMP(millis)"},
+		{Text: "STRING_FORMAT", Description: "STRING_FORMAT(pattern [, args...])"},
+		{Text: "TIMESTAMP_TO_MILLIS", Description: "TIMESTAMP_TO_MILLIS(timestamp)"},
+		{Text: "TIME_CEIL", Description: "TIME_CEIL(timestamp, period [, origin [, timeZone]])"},
+		{Text: "TIME_EXTRACT", Description: "TIME_EXTRACT(timestamp [, unit [, timeZone]])"},
+		{Text: "TIME_FLOOR", Description: "TIME_FLOOR(timestamp, period [, origin [, timeZone]])"},
+		{Text: "TIME_FORMAT", Description: "TIME_FORMAT(timestamp [, pattern [, timeZone]])"},
+		{Text: "TIME_PARSE", Description: "TIME_PARSE(string [, pattern [, timeZone]])"},
+		{Text: "TIME_SHIFT", Description: "TIME_SHIFT(timestamp, period, step [, timeZone])"},
+	},
+	DialectHive: {
+		{Text: "CLUSTER", Description: "Used in CLUSTER BY to distribute and sort rows by the same columns."},
+		{Text: "DISTRIBUTE", Description: "Used in DISTRIBUTE BY to send rows with equal keys to one reducer."},
+		{Text: "SORT", Description: "Used in SORT BY to sort rows within each reducer."},
+		{Text: "OVERWRITE", Description: "Used in INSERT OVERWRITE to replace the contents of a table."},
+		{Text: "PARTITIONED", Description: "Used in PARTITIONED BY to declare the partition columns."},
+		{Text: "STORED", Description: "Used in STORED AS to choose the file format."},
+		{Text: "TBLPROPERTIES", Description: "Sets the properties of a table."},
+		{Text: "COLLECT_LIST", Description: "COLLECT_LIST(value)"},
+		{Text: "COLLECT_SET", Description: "COLLECT_SET(value)"},
+		{Text: "CONCAT_WS", Description: "CONCAT_WS(separator, string [, string]*)"},
+		{Text: "DATE_FORMAT", Description: "DATE_FORMAT(date, pattern)"},
+		{Text: "EXPLODE", Description: "EXPLODE(array | map)"},
+		{Text: "FROM_UNIXTIME", Description: "FROM_UNIXTIME(seconds [, pattern])"},
+		{Text: "GET_JSON_OBJECT", Description: "GET_JSON_OBJECT(json, path)"},
+		{Text: "NVL", Description: "NVL(value, default)"},
+		{Text: "POSEXPLODE", Description: "POSEXPLODE(array)"},
+		{Text: "REGEXP_EXTRACT", Description: "REGEXP_EXTRACT(string, pattern [, index])"},
+		{Text: "UNIX_TIMESTAMP", Description: "UNIX_TIMESTAMP([string [, pattern]])"},
+	},
+}
+
+// dialectKeywords holds the completed keywords and functions of each
+// dialect, built once from sqlSuggestions and dialectExtensions
+var dialectKeywords = map[string][]prompt.Suggest{}
+
+// dialectKeywordNames holds the same words in upper case, for highlighting
+var dialectKeywordNames = map[string]map[string]bool{}
+
+func init() {
+	for _, name := range Dialects[1:] {
+		keywords := append([]prompt.Suggest{}, sqlSuggestions...)
+		keywords = append(keywords, dialectExtensions[name]...)
+		dialectKeywords[name] = withSignatures(keywords)
+
+		names := map[string]bool{}
+		for _, k := range dialectKeywords[name] {
+			names[strings.ToUpper(k.Text)] = true
+		}
+		dialectKeywordNames[name] = names
+	}
+}
+
+// detectDialect picks the dialect of a server from the database product
+// name it reports, such as "Phoenix" or "Apache Hive"
+func detectDialect(product string) string {
+	product = strings.ToLower(product)
+	for _, name := range []string{DialectPhoenix, DialectDruid, DialectHive} {
+		if strings.Contains(product, name) {
+			return name
+		}
+	}
+	return DialectCalcite
+}
+
+// setDialect switches completion to the keywords of a dialect. With
+// DialectAuto the dialect is detected once the server's metadata arrives.
+func (s *PromptSession) setDialect(name string) {
+	s.dialect = name
+	s.detectedFrom = nil
+	s.activeDialect = name
+	if name == DialectAuto {
+		s.activeDialect = DialectCalcite
+	}
+	s.keywords = dialectKeywords[s.activeDialect]
+}
+
+// sqlKeywords returns the keywords and functions to complete, following
+// the product name in meta when the dialect is detected automatically. It
+// runs on every key press, so the dialect is only detected again when the
+// metadata has changed.
+func (s *PromptSession) sqlKeywords(meta *metadata) []prompt.Suggest {
+	if s.dialect == DialectAuto && meta != s.detectedFrom {
+		s.detectedFrom = meta
+		if meta != nil && meta.product != "" {
+			s.activeDialect = detectDialect(meta.product)
+			s.keywords = dialectKeywords[s.activeDialect]
+		}
+	}
+	return s.keywords
+}
+
+// isKeyword reports whether word is a keyword or function of the dialect in
+// use
+func (s *PromptSession) isKeyword(word string) bool {
+	name := s.activeDialect
+	if name == "" {
+		name = DialectCalcite
+	}
+	return dialectKeywordNames[name][strings.ToUpper(word)]
+}
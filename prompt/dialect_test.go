@@ -0,0 +1,59 @@
+package prompt
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectDialect(t *testing.T) {
+	tests := []struct {
+		product string
+		want    string
+	}{
+		{"Phoenix", DialectPhoenix},
+		{"Apache Hive", DialectHive},
+		{"Druid", DialectDruid},
+		{"Calcite", DialectCalcite},
+		{"", DialectCalcite},
+	}
+	for _, tt := range tests {
+		if got := detectDialect(tt.product); got != tt.want {
+			t.Errorf("detectDialect(%q) = %s, want %s", tt.product, got, tt.want)
+		}
+	}
+}
+
+func TestDialectKeywords(t *testing.T) {
+	session := &PromptSession{catalog: newMetadataCache(nil), match: MatchPrefix}
+	completes := func(input, want string) bool {
+		return slices.Contains(suggestionTexts(session.completer(completionDocument(input, ""))), want)
+	}
+
+	// Until the server is known, auto completes Calcite keywords
+	session.setDialect(DialectAuto)
+	if completes("UPS", "UPSERT") {
+		t.Error("Expected no UPSERT for Calcite")
+	}
+	if !completes("SEL", "SELECT") {
+		t.Error("Expected SELECT for Calcite")
+	}
+
+	session.catalog.meta = &metadata{product: "Phoenix"}
+	if !completes("UPS", "UPSERT") || !completes("SALT", "SALT_BUCKETS") {
+		t.Error("Expected Phoenix keywords once the server is detected")
+	}
+	if got := settings["dialect"].get(session); got != "auto (phoenix)" {
+		t.Errorf("Expected the detected dialect to be shown, got %q", got)
+	}
+
+	// An explicit dialect is kept whatever the server
+	if err := settings["dialect"].set(session, "HIVE"); err != nil {
+		t.Fatalf("Unexpected error setting dialect: %s", err)
+	}
+	if completes("UPS", "UPSERT") || !completes("EXPL", "EXPLODE") {
+		t.Error("Expected Hive keywords after \\set dialect hive")
+	}
+	if err := settings["dialect"].set(session, "oracle"); err == nil {
+		t.Error("Expected an unknown dialect to be rejected")
+	}
+}
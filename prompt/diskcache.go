@@ -0,0 +1,142 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// metadataFile is the JSON form of metadata kept between sessions
+type metadataFile struct {
+	Saved    time.Time            `json:"saved"`
+	Catalogs []string             `json:"catalogs,omitempty"`
+	Schemas  []metadataFileSchema `json:"schemas,omitempty"`
+	Tables   []metadataFileTable  `json:"tables,omitempty"`
+	// Product and Functions describe the server's own functions
+	Product   string   `json:"product,omitempty"`
+	Functions []string `json:"functions,omitempty"`
+	// Loaded lists the schemas whose columns are included
+	Loaded []metadataFileSchema `json:"loaded,omitempty"`
+}
+
+type metadataFileSchema struct {
+	Catalog string `json:"catalog,omitempty"`
+	Name    string `json:"name"`
+}
+
+type metadataFileTable struct {
+	Catalog string               `json:"catalog,omitempty"`
+	Schema  string               `json:"schema,omitempty"`
+	Name    string               `json:"name"`
+	Kind    string               `json:"kind,omitempty"`
+	Columns []metadataFileColumn `json:"columns,omitempty"`
+}
+
+type metadataFileColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type,omitempty"`
+	Nullable string `json:"nullable,omitempty"`
+}
+
+// readMetadataFile reads a file written by writeMetadataFile, returning the
+// schemas whose columns it holds along with it
+func readMetadataFile(path string) (*metadataFile, [][2]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var f metadataFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, nil, err
+	}
+	var loaded [][2]string
+	for _, s := range f.Loaded {
+		loaded = append(loaded, [2]string{s.Catalog, s.Name})
+	}
+	return &f, loaded, nil
+}
+
+// metadata converts the file contents back to metadata
+func (f *metadataFile) metadata() *metadata {
+	m := &metadata{catalogs: f.Catalogs, product: f.Product, functions: f.Functions}
+	for _, s := range f.Schemas {
+		m.addSchema(s.Catalog, s.Name)
+	}
+	for _, t := range f.Tables {
+		table := m.addTable(t.Catalog, t.Schema, t.Name)
+		table.kind = t.Kind
+		for _, c := range t.Columns {
+			table.columns = append(table.columns, columnInfo{name: c.Name, typeName: c.Type, nullable: c.Nullable})
+		}
+	}
+	return m
+}
+
+// writeMetadataFile saves m along with the schemas whose columns are
+// loaded. The file is replaced in one step so that a session starting at
+// the same time never reads half of it.
+func writeMetadataFile(path string, m *metadata, loaded [][2]string) error {
+	f := metadataFile{Saved: time.Now(), Catalogs: m.catalogs, Product: m.product, Functions: m.functions}
+	for _, s := range m.schemaList {
+		f.Schemas = append(f.Schemas, metadataFileSchema{Catalog: s.catalog, Name: s.name})
+	}
+	for _, t := range m.tables {
+		table := metadataFileTable{Catalog: t.catalog, Schema: t.schema, Name: t.name, Kind: t.kind}
+		for _, c := range t.columns {
+			table.Columns = append(table.Columns, metadataFileColumn{Name: c.name, Type: c.typeName, Nullable: c.nullable})
+		}
+		f.Tables = append(f.Tables, table)
+	}
+	slices.SortFunc(loaded, func(a, b [2]string) int {
+		return strings.Compare(a[0]+"."+a[1], b[0]+"."+b[1])
+	})
+	for _, key := range loaded {
+		f.Loaded = append(f.Loaded, metadataFileSchema{Catalog: key[0], Name: key[1]})
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package prompt
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMetadataFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "meta.json")
+	m := &metadata{catalogs: []string{"CAT"}}
+	m.addSchema("CAT", "EMPTY")
+	m.addTable("CAT", "SALES", "ORDERS").columns = []columnInfo{{"ID", "INTEGER", "NO"}}
+	m.addTable("CAT", "SALES", "TOTALS").kind = "VIEW"
+	if err := writeMetadataFile(path, m, [][2]string{{"CAT", "SALES"}}); err != nil {
+		t.Fatalf("Unexpected error writing metadata: %s", err)
+	}
+
+	f, loaded, err := readMetadataFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reading metadata: %s", err)
+	}
+	if time.Since(f.Saved) > time.Minute {
+		t.Errorf("Expected the save time to be recorded, got %s", f.Saved)
+	}
+	if len(loaded) != 1 || loaded[0] != [2]string{"CAT", "SALES"} {
+		t.Errorf("Expected CAT.SALES to be loaded, got %v", loaded)
+	}
+	got := f.metadata()
+	if len(got.catalogs) != 1 || len(got.schemaList) != 1 || len(got.tables) != 2 {
+		t.Fatalf("Expected metadata to round-trip, got %+v", got)
+	}
+	if orders := got.lookupTable([]string{"SALES", "ORDERS"}); len(orders) != 1 || orders[0].columns[0] != (columnInfo{"ID", "INTEGER", "NO"}) {
+		t.Errorf("Expected ORDERS with its column, got %v", orders)
+	}
+	if totals := got.lookupTable([]string{"TOTALS"}); len(totals) != 1 || totals[0].kind != "VIEW" {
+		t.Errorf("Expected view TOTALS, got %v", totals)
+	}
+}
+
+func TestMetadataCacheFile(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	path := filepath.Join(t.TempDir(), "meta.json")
+	newCache := func() *metadataCache {
+		c := newMetadataCache(&metadataSource{db: db})
+		c.path = path
+		c.ttl = time.Hour
+		return c
+	}
+	expectTables := func(names ...string) {
+		mock.ExpectQuery("INFORMATION_SCHEMA.SCHEMATA").WillReturnError(errors.New("no such table"))
+		rows := sqlmock.NewRows([]string{"TABLE_CATALOG", "TABLE_SCHEMA", "TABLE_NAME", "TABLE_TYPE"})
+		for _, name := range names {
+			rows.AddRow(nil, "SALES", name, "TABLE")
+		}
+		mock.ExpectQuery("INFORMATION_SCHEMA.TABLES").WillReturnRows(rows)
+	}
+
+	// Without a file the metadata is loaded from the server and saved
+	expectTables("ORDERS")
+	mock.ExpectQuery("INFORMATION_SCHEMA.COLUMNS").WithArgs("SALES").WillReturnRows(
+		sqlmock.NewRows([]string{"TABLE_CATALOG", "TABLE_SCHEMA", "TABLE_NAME", "COLUMN_NAME", "DATA_TYPE", "IS_NULLABLE"}).
+			AddRow(nil, "SALES", "ORDERS", "ID", "INTEGER", "NO"))
+	c := newCache()
+	c.start()
+	c.wait()
+	c.loadColumns(c.snapshot().tables)
+	c.wait()
+
+	// A fresh file is used without asking the server, columns included
+	c = newCache()
+	c.start()
+	c.loadColumns(c.snapshot().tables)
+	c.wait()
+	if orders := c.snapshot().lookupTable([]string{"ORDERS"}); len(orders) != 1 || len(orders[0].columns) != 1 {
+		t.Fatalf("Expected ORDERS and its columns from the file, got %v", orders)
+	}
+
+	// A stale file is shown at once and replaced in the background
+	f, _, err := readMetadataFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reading metadata: %s", err)
+	}
+	f.Saved = time.Now().Add(-2 * time.Hour)
+	data, _ := json.Marshal(f)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Unexpected error aging metadata: %s", err)
+	}
+	expectTables("ORDERS", "UNITS")
+	c = newCache()
+	c.start()
+	if got := len(c.snapshot().lookupTable([]string{"ORDERS"})); got != 1 {
+		t.Errorf("Expected the stale file to be used until reloaded, got %d tables", got)
+	}
+	c.wait()
+	if got := len(c.snapshot().tables); got != 2 {
+		t.Errorf("Expected the reloaded tables, got %d", got)
+	}
+	// The columns of the file are kept until they are loaded again
+	if orders := c.snapshot().lookupTable([]string{"ORDERS"}); len(orders) != 1 || len(orders[0].columns) != 1 {
+		t.Errorf("Expected the columns of ORDERS to be kept, got %v", orders)
+	}
+
+	// \refresh --hard removes the file and forgets everything
+	expectTables("ORDERS")
+	if err := c.hardRefresh(); err != nil {
+		t.Fatalf("Unexpected error in hard refresh: %s", err)
+	}
+	c.wait()
+	if got := len(c.snapshot().tables); got != 1 {
+		t.Errorf("Expected 1 table after a hard refresh, got %d", got)
+	}
+	if f, _, err := readMetadataFile(path); err != nil || len(f.Tables) != 1 {
+		t.Errorf("Expected the file to be written again, got %v, %v", f, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
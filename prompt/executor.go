@@ -20,80 +20,240 @@ package prompt
 import (
 	"database/sql"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	_ "github.com/apache/calcite-avatica-go/v5"
 	"github.com/c-bata/go-prompt"
 	calcitesql "github.com/satyakommula96/calcite-cli/calcitesql"
 )
 
+// Options configures a prompt session from the command line
+type Options struct {
+	// ShowConnectionID displays the Avatica connection ID in the prompt
+	ShowConnectionID bool
+	// Safe refuses statements that could modify data
+	Safe bool
+	// AssumeYes runs destructive statements without asking for confirmation
+	AssumeYes bool
+	// ReadOnly makes the pinned connection read-only on the server
+	ReadOnly bool
+	// Isolation is the transaction isolation level of the pinned
+	// connection, one of IsolationLevels
+	Isolation string
+	// Display holds the initial result display settings
+	Display calcitesql.Options
+	// Pager is the pager mode: auto, on or off
+	Pager string
+	// Output is a file that receives query results instead of the terminal
+	Output string
+	// DSN is the avatica-go connection string, used for metadata requests
+	DSN string
+	// MetadataCache is the file completion metadata is kept in between
+	// sessions, empty to always load it from the server
+	MetadataCache string
+	// Match is the completion matching mode, MatchFuzzy or MatchPrefix
+	Match string
+	// KeywordCase is the case completed keywords are inserted in,
+	// KeywordUpper or KeywordLower
+	KeywordCase string
+	// Highlight colours the input line by token
+	Highlight bool
+	// Theme colours the prompt and the results, nil for the dark theme. It
+	// is replaced by the mono theme when NO_COLOR is set or stdout is not a
+	// terminal.
+	Theme *Theme
+	// Dialect selects the keywords and functions completed, DialectAuto to
+	// detect it from the server
+	Dialect string
+	// MetadataCacheTTL is how long the metadata file is shown at startup
+	// while the metadata is reloaded in the background
+	MetadataCacheTTL time.Duration
+}
+
 type PromptSession struct {
-	db             *sql.DB
+	db   *sql.DB
+	conn *sql.Conn
+	// connID is the Avatica connection ID of conn
+	connID         string
 	isMultiline    bool
 	multiLineQuery strings.Builder
-	suggestions    []prompt.Suggest
+	keywords       []prompt.Suggest
+	dialect        string
+	activeDialect  string
+	// detectedFrom is the metadata activeDialect was last detected from
+	detectedFrom *metadata
+	catalog      *metadataCache
+	match        string
+	keywordCase  string
+	highlight    bool
+	tx           *sql.Tx
+	isolation    sql.IsolationLevel
+	readOnly     bool
+	// rpc sends the Avatica requests database/sql has no API for, nil when
+	// the server cannot be reached that way
+	rpc              *avaticaClient
+	exitWarned       bool
+	showConnectionID bool
+	safe             bool
+	confirm          bool
+	confirmIn        io.Reader
+	display          calcitesql.Options
+	pager            string
+	// output receives query results when set with \o
+	output *os.File
 }
 
-func CreateAndRunPrompt(db *sql.DB) {
-	fmt.Println("Welcome! Use SQL to query Apache Calcite.\nUse Ctrl+D, type \"exit\" or \"quit\" to exit.")
+func CreateAndRunPrompt(db *sql.DB, opts Options) {
+	fmt.Println("Welcome! Use SQL to query Apache Calcite.\nUse Ctrl+D, type \"exit\" or \"quit\" to exit, \\? for help.")
 	fmt.Println()
 
-	session := &PromptSession{db: db}
-
-	// Initialize with static SQL suggestions
-	session.suggestions = append(session.suggestions, sqlSuggestions...)
-
-	// Fetch database-specific tables and columns
-	metaSugg := fetchMetadataSuggestions(db)
-	session.suggestions = append(session.suggestions, metaSugg...)
-
-	p := prompt.New(
-		session.executor,
-		session.completer,
-		prompt.OptionLivePrefix(session.LivePrefix),
-		prompt.OptionPrefixTextColor(prompt.Yellow),
-		prompt.OptionPreviewSuggestionTextColor(prompt.Blue),
-		prompt.OptionSuggestionBGColor(prompt.White),
-		prompt.OptionSuggestionTextColor(prompt.Black),
-		prompt.OptionSelectedSuggestionBGColor(prompt.DarkGray),
-		prompt.OptionSelectedSuggestionTextColor(prompt.White),
+	session := &PromptSession{
+		db:               db,
+		showConnectionID: opts.ShowConnectionID,
+		safe:             opts.Safe,
+		confirm:          !opts.AssumeYes,
+		display:          opts.Display,
+		pager:            opts.Pager,
+		match:            opts.Match,
+		keywordCase:      opts.KeywordCase,
+		highlight:        opts.Highlight,
+		readOnly:         opts.ReadOnly,
+		isolation:        isolationLevels[opts.Isolation],
+	}
+	if opts.DSN != "" {
+		rpc, err := newAvaticaClient(opts.DSN)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Using INFORMATION_SCHEMA for metadata:", err)
+		}
+		session.rpc = rpc
+	}
+	if err := session.connect(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error acquiring connection:", err)
+		return
+	}
+	// The pinned connection is replaced by \reconnect, so close the last one
+	defer func() {
+		if session.conn != nil {
+			session.conn.Close()
+		}
+	}()
+	if opts.Output != "" {
+		if err := session.setOutput(opts.Output); err != nil {
+			fmt.Fprintln(os.Stderr, "Error opening output file:", err)
+			return
+		}
+	}
+	defer session.closeOutput()
+
+	// Initialize with the keywords of the chosen dialect, or of Calcite
+	// until the server's product name is known
+	session.setDialect(opts.Dialect)
+
+	// Fetch database-specific catalogs, schemas and tables in the background,
+	// starting from those saved by an earlier session
+	source := &metadataSource{db: db, rpc: session.rpc}
+	session.catalog = newMetadataCache(source)
+	session.catalog.path = opts.MetadataCache
+	session.catalog.ttl = opts.MetadataCacheTTL
+	defer source.close()
+	session.catalog.start()
+
+	// The input line is coloured by the writer, which has to see the prefix
+	// to find where the input starts
+	theme := themes[ThemeDark]
+	if opts.Theme != nil {
+		theme = *opts.Theme
+	}
+	if monochrome() {
+		theme = themes[ThemeMono]
+	}
+	session.applyTheme(theme)
+	writer := &highlightWriter{ConsoleWriter: prompt.NewStdoutWriter(), session: session, colors: theme.highlight}
+
+	options := append(theme.promptOptions(),
+		prompt.OptionWriter(writer),
+		prompt.OptionLivePrefix(writer.livePrefix),
 		prompt.OptionCompletionOnDown(),
-		prompt.OptionTitle("Calcite CLI Prompt"),                 // Set a title for the prompt
-		prompt.OptionInputTextColor(prompt.Fuchsia),              // Customize input text color
-		prompt.OptionDescriptionTextColor(prompt.Black),          // Customize description text color
-		prompt.OptionSelectedSuggestionTextColor(prompt.White),   // Customize selected suggestion text color
-		prompt.OptionSelectedSuggestionBGColor(prompt.LightGray), // Customize selected suggestion background color
-		prompt.OptionPrefix("calcite \U0001F48E:sql> "),          // Set a custom prefix for the prompt
+		prompt.OptionCompletionWordSeparator(completionWordSeparator),
+		prompt.OptionTitle("Calcite CLI Prompt"),        // Set a title for the prompt
+		prompt.OptionPrefix("calcite \U0001F48E:sql> "), // Set a custom prefix for the prompt
 	)
+	p := prompt.New(session.executor, session.completer, options...)
 
 	p.Run()
+
+	// Ctrl+D leaves the prompt without going through the executor
+	session.discardTransaction()
 }
 
 func (s *PromptSession) LivePrefix() (prefix string, useLivePrefix bool) {
 	if s.isMultiline {
 		prefix = "... "
 		useLivePrefix = true
+	} else if s.tx != nil {
+		prefix = "calcite \U0001F48E:sql(tx)> "
+		useLivePrefix = true
 	} else {
 		prefix = "calcite \U0001F48E:sql> "
 		useLivePrefix = !s.isMultiline
 	}
+	if s.showConnectionID && !s.isMultiline {
+		if id := s.connectionID(); len(id) >= 8 {
+			prefix = "[" + id[:8] + "] " + prefix
+		}
+	}
 	return prefix, useLivePrefix
 }
 
 func (s *PromptSession) executor(query string) {
 	// Check for exit command
 	if strings.ToLower(query) == "exit" || strings.ToLower(query) == "quit" {
+		// Give the user a chance to commit before uncommitted work is thrown away
+		if s.tx != nil && !s.exitWarned {
+			fmt.Fprintln(os.Stderr, "WARNING: a transaction is in progress. COMMIT or ROLLBACK it, or exit again to discard it.")
+			s.exitWarned = true
+			return
+		}
+		s.discardTransaction()
 		fmt.Println("Exiting calcite CLI Prompt...")
 		os.Exit(0)
 	}
+	s.exitWarned = false
 
 	trimmedQuery := strings.TrimSpace(query)
 
+	// Backslash commands are handled locally and need no terminating semicolon
+	if !s.isMultiline && strings.HasPrefix(trimmedQuery, "\\") {
+		s.runMetaCommand(trimmedQuery)
+		return
+	}
+
+	// A statement ending in \G instead of ; is displayed in expanded mode
+	expanded := strings.HasSuffix(trimmedQuery, "\\G")
+	if expanded {
+		trimmedQuery = strings.TrimSuffix(trimmedQuery, "\\G")
+	}
+
 	// Check if it is a multiline query
-	if strings.HasSuffix(trimmedQuery, ";") {
+	if expanded || strings.HasSuffix(trimmedQuery, ";") {
 		s.multiLineQuery.WriteString(trimmedQuery)
-		calcitesql.ExecuteQuery(s.db, s.multiLineQuery.String())
+		if s.conn == nil {
+			// A previous reconnect failed, try again before giving up on the statement
+			if err := s.connect(); err != nil {
+				fmt.Fprintln(os.Stderr, "Error acquiring connection:", err)
+				s.multiLineQuery.Reset()
+				s.isMultiline = false
+				return
+			}
+		}
+		if cmd := transactionCommand(s.multiLineQuery.String()); cmd != "" {
+			s.handleTransaction(cmd)
+		} else if s.allowed(s.multiLineQuery.String()) {
+			s.runQuery(s.multiLineQuery.String(), expanded)
+		}
 		s.multiLineQuery.Reset()
 		s.isMultiline = false
 	} else {
@@ -106,46 +266,42 @@ func (s *PromptSession) executor(query string) {
 	}
 }
 
-func (s *PromptSession) completer(d prompt.Document) []prompt.Suggest {
-	input := d.GetWordBeforeCursor()
-	if input == "" {
-		return nil
+// allowed reports whether a statement may run, applying safe mode and asking
+// for confirmation of destructive statements
+func (s *PromptSession) allowed(query string) bool {
+	st := calcitesql.Classify(query)
+	if s.safe && !st.ReadOnly {
+		fmt.Fprintf(os.Stderr, "Statement blocked by safe mode: %s could modify data. Use \\safe off to allow it.\n", st.Trigger)
+		return false
 	}
-	return prompt.FilterHasPrefix(s.suggestions, input, true)
+	return s.confirmDestructive(st, query)
 }
 
-func fetchMetadataSuggestions(db *sql.DB) []prompt.Suggest {
-	var suggestions []prompt.Suggest
-
-	// Fetch tables
-	tableRows, err := db.Query("SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES")
-	if err == nil {
-		defer tableRows.Close()
-		for tableRows.Next() {
-			var tableName string
-			if err := tableRows.Scan(&tableName); err == nil {
-				suggestions = append(suggestions, prompt.Suggest{
-					Text:        tableName,
-					Description: "Table Name",
-				})
-			}
+// runQuery executes a statement on the session and displays its result,
+// forcing the expanded layout if requested with \G
+func (s *PromptSession) runQuery(query string, expanded bool) {
+	var display calcitesql.Options
+	var out io.WriteCloser
+	if s.output != nil {
+		display = fileDisplay(s.display, s.output)
+	} else {
+		out = newPagerWriter(s.pager)
+		display = s.display
+		display.Out = out
+		if width, _, ok := terminalSize(); ok {
+			display.TermWidth = width
 		}
 	}
-
-	// Fetch columns
-	columnRows, err := db.Query("SELECT DISTINCT COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS")
-	if err == nil {
-		defer columnRows.Close()
-		for columnRows.Next() {
-			var columnName string
-			if err := columnRows.Scan(&columnName); err == nil {
-				suggestions = append(suggestions, prompt.Suggest{
-					Text:        columnName,
-					Description: "Column Name",
-				})
-			}
-		}
+	if expanded {
+		display.Expanded = calcitesql.ExpandedOn
 	}
-
-	return suggestions
+	err := calcitesql.ExecuteQuery(s.queryer(), query, display)
+	if out != nil {
+		out.Close()
+	}
+	if err == nil && schemaKeywords[calcitesql.Classify(query).Keyword] {
+		// Tables may have been created, dropped or altered
+		s.catalog.refresh()
+	}
+	s.checkConnection(err)
 }
@@ -1,6 +1,7 @@
 package prompt
 
 import (
+	"database/sql"
 	"reflect"
 	"testing"
 	"unsafe"
@@ -9,64 +10,60 @@ import (
 	"github.com/c-bata/go-prompt"
 )
 
-func TestFetchMetadataSuggestions(t *testing.T) {
+// completionDocument returns the input line with the cursor after before
+func completionDocument(before, after string) prompt.Document {
+	doc := prompt.Document{
+		Text: before + after,
+	}
+	// Set unexported cursorPosition field using reflection and unsafe
+	field := reflect.ValueOf(&doc).Elem().FieldByName("cursorPosition")
+	ptr := unsafe.Pointer(field.UnsafeAddr())
+	*(*int)(ptr) = len(before)
+	return doc
+}
+
+// newMockSession returns a session pinned to a connection of a sqlmock
+// database, both closed when the test ends
+func newMockSession(t *testing.T) (*PromptSession, *sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
 	}
-	defer db.Close()
-
-	// Mock table names query
-	tableRows := sqlmock.NewRows([]string{"TABLE_NAME"}).
-		AddRow("USERS").
-		AddRow("ORDERS")
-	mock.ExpectQuery("SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES").WillReturnRows(tableRows)
-
-	// Mock column names query
-	columnRows := sqlmock.NewRows([]string{"COLUMN_NAME"}).
-		AddRow("ID").
-		AddRow("NAME")
-	mock.ExpectQuery("SELECT DISTINCT COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS").WillReturnRows(columnRows)
-
-	suggestions := fetchMetadataSuggestions(db)
-
-	if len(suggestions) != 4 {
-		t.Errorf("Expected 4 suggestions, got %d", len(suggestions))
-	}
+	t.Cleanup(func() { db.Close() })
 
-	expected := map[string]string{
-		"USERS":  "Table Name",
-		"ORDERS": "Table Name",
-		"ID":     "Column Name",
-		"NAME":   "Column Name",
+	session := &PromptSession{db: db}
+	if err := session.connect(); err != nil {
+		t.Fatalf("Unexpected error pinning connection: %s", err)
 	}
-
-	for _, s := range suggestions {
-		desc, exists := expected[s.Text]
-		if !exists {
-			t.Errorf("Unexpected suggestion: %s", s.Text)
-		} else if desc != s.Description {
-			t.Errorf("For %s, expected description %q, got %q", s.Text, desc, s.Description)
+	t.Cleanup(func() {
+		if session.conn != nil {
+			session.conn.Close()
 		}
-	}
-
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("There were unfulfilled expectations: %s", err)
-	}
+	})
+	return session, db, mock
 }
 
 func TestPromptSessionCompleter(t *testing.T) {
 	session := &PromptSession{
-		suggestions: []prompt.Suggest{
+		keywords: []prompt.Suggest{
 			{Text: "SELECT", Description: "SQL Keyword"},
-			{Text: "USERS", Description: "Table Name"},
-			{Text: "USER_ID", Description: "Column Name"},
+			{Text: "UPPER", Description: "SQL Function"},
+			{Text: "WHERE", Description: "SQL Keyword"},
 		},
+		catalog: newMetadataCache(nil),
+		match:   MatchPrefix,
 	}
+	meta := session.catalog.snapshot()
+	meta.addTable("", "", "USERS").columns = columnsNamed("USER_ID")
+	meta.addTable("", "SALES", "ORDERS").columns = columnsNamed("ORDER_ID", "USER_ID")
+	meta.addTable("", "SALES", "UNITS").columns = columnsNamed("UNIT")
 
 	tests := []struct {
-		input string
-		want  []string
+		input     string
+		after     string
+		multiline string
+		want      []string
 	}{
 		{
 			input: "SEL",
@@ -74,7 +71,7 @@ func TestPromptSessionCompleter(t *testing.T) {
 		},
 		{
 			input: "US",
-			want:  []string{"USERS", "USER_ID"},
+			want:  []string{},
 		},
 		{
 			input: "XYZ",
@@ -84,18 +81,96 @@ func TestPromptSessionCompleter(t *testing.T) {
 			input: "",
 			want:  []string{},
 		},
+		{
+			input: "SELECT U",
+			want:  []string{"USER_ID", "UNIT", "UPPER"},
+		},
+		{
+			input: "SELECT COUNT(U",
+			want:  []string{"USER_ID", "UNIT", "UPPER"},
+		},
+		{
+			input: "SELECT * FROM U",
+			want:  []string{"USERS", "UNITS"},
+		},
+		{
+			input: "SELECT * FROM USERS U",
+			want:  []string{"UPPER"},
+		},
+		{
+			input: "SELECT * FROM A, U",
+			want:  []string{"USERS", "UNITS"},
+		},
+		{
+			input: "SELECT * FROM USERS WHERE US",
+			want:  []string{"USER_ID"},
+		},
+		{
+			input: "SELECT * FROM (SELECT USER_ID FROM USERS) WHERE US",
+			want:  []string{"USER_ID"},
+		},
+		{
+			input: "SELECT * FROM USERS WHERE U IN (SELECT U",
+			want:  []string{"USER_ID", "UPPER"},
+		},
+		{
+			input: "SELECT 1 FROM USERS; SEL",
+			want:  []string{"SELECT"},
+		},
+		{
+			input: "SELECT * FROM USERS WHERE NAME = 'US",
+			want:  []string{},
+		},
+		{
+			input:     "US",
+			multiline: "SELECT * FROM ",
+			want:      []string{"USERS"},
+		},
+		{
+			input: "SELECT * FROM S",
+			want:  []string{"SALES"},
+		},
+		{
+			input: "SELECT * FROM SALES.",
+			want:  []string{"ORDERS", "UNITS"},
+		},
+		{
+			input: "SELECT * FROM sales.U",
+			want:  []string{"UNITS"},
+		},
+		{
+			input: "SELECT o.",
+			after: " FROM SALES.ORDERS o",
+			want:  []string{"ORDER_ID", "USER_ID"},
+		},
+		{
+			input: "SELECT * FROM USERS u JOIN ORDERS AS o ON u.",
+			want:  []string{"USER_ID"},
+		},
+		{
+			input: "SELECT * FROM USERS u, UNITS x WHERE x.",
+			want:  []string{"UNIT"},
+		},
+		{
+			input: "SELECT ORDERS.O",
+			want:  []string{"ORDER_ID"},
+		},
+		{
+			input: "SELECT U",
+			after: " FROM UNITS",
+			want:  []string{"UNIT", "UPPER"},
+		},
+		{
+			input: "SELECT z.",
+			want:  []string{},
+		},
 	}
 
 	for _, tt := range tests {
-		doc := prompt.Document{
-			Text: tt.input,
-		}
-		// Set unexported cursorPosition field using reflection and unsafe
-		field := reflect.ValueOf(&doc).Elem().FieldByName("cursorPosition")
-		ptr := unsafe.Pointer(field.UnsafeAddr())
-		*(*int)(ptr) = len(tt.input)
-
-		got := session.completer(doc)
+		session.isMultiline = tt.multiline != ""
+		session.multiLineQuery.Reset()
+		session.multiLineQuery.WriteString(tt.multiline)
+		got := session.completer(completionDocument(tt.input, tt.after))
 		if len(got) != len(tt.want) {
 			t.Errorf("For input %q, expected %d suggestions, got %d", tt.input, len(tt.want), len(got))
 			continue
@@ -107,3 +182,38 @@ func TestPromptSessionCompleter(t *testing.T) {
 		}
 	}
 }
+
+func TestPromptSessionSafeMode(t *testing.T) {
+	session, _, mock := newMockSession(t)
+	session.runMetaCommand(`\safe on`)
+
+	// Only the SELECT is expected to reach the server
+	mock.ExpectQuery("SELECT \\* FROM T").WillReturnRows(sqlmock.NewRows([]string{"ID"}))
+
+	session.executor("DELETE FROM T;")
+	session.executor("SELECT * FROM T;")
+	session.executor("UPSERT INTO T")
+	session.executor("SELECT * FROM S;")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPromptSessionExpandedTerminator(t *testing.T) {
+	session, _, mock := newMockSession(t)
+
+	mock.ExpectQuery("^SELECT \\* FROM T$").WillReturnRows(sqlmock.NewRows([]string{"ID"}).AddRow(1))
+	mock.ExpectQuery("^SELECT 1 FROM S$").WillReturnRows(sqlmock.NewRows([]string{"ID"}).AddRow(1))
+
+	session.executor("SELECT * FROM T")
+	session.executor(`\G`)
+	session.executor(`SELECT 1 FROM S\G`)
+	if session.isMultiline {
+		t.Error("Expected \\G to end the statement")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
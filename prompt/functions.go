@@ -0,0 +1,240 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+)
+
+// helpTopic documents a function or statement for completion and \h
+type helpTopic struct {
+	name        string
+	syntax      string
+	description string
+	examples    []string
+}
+
+// sqlFunctions is the catalogue of standard Calcite functions. Completion
+// shows their signatures and \h their descriptions and examples.
+var sqlFunctions = []helpTopic{
+	// Numeric
+	{"ABS", "ABS(numeric)", "Returns the absolute value of numeric.", []string{"ABS(-3) -- 3"}},
+	{"CEIL", "CEIL(numeric) | CEIL(datetime TO timeUnit)", "Rounds numeric up, or a date or timestamp up to a unit.", []string{"CEIL(2.1) -- 3", "CEIL(TIMESTAMP '2024-05-06 10:30:00' TO DAY)"}},
+	{"EXP", "EXP(numeric)", "Returns e raised to the power of numeric.", []string{"EXP(1)"}},
+	{"FLOOR", "FLOOR(numeric) | FLOOR(datetime TO timeUnit)", "Rounds numeric down, or a date or timestamp down to a unit.", []string{"FLOOR(2.9) -- 2", "FLOOR(ORDER_TIME TO HOUR)"}},
+	{"LN", "LN(numeric)", "Returns the natural logarithm of numeric.", []string{"LN(EXP(2)) -- 2"}},
+	{"LOG10", "LOG10(numeric)", "Returns the base 10 logarithm of numeric.", []string{"LOG10(1000) -- 3"}},
+	{"MOD", "MOD(numeric1, numeric2)", "Returns the remainder of numeric1 divided by numeric2.", []string{"MOD(7, 3) -- 1"}},
+	{"POWER", "POWER(numeric1, numeric2)", "Returns numeric1 raised to the power of numeric2.", []string{"POWER(2, 10) -- 1024"}},
+	{"RAND", "RAND([seed])", "Returns a random double between 0 and 1.", []string{"RAND()"}},
+	{"RAND_INTEGER", "RAND_INTEGER([seed, ] numeric)", "Returns a random integer between 0 and numeric - 1.", []string{"RAND_INTEGER(10)"}},
+	{"ROUND", "ROUND(numeric1 [, numeric2])", "Rounds numeric1 to numeric2 decimal places.", []string{"ROUND(3.14159, 2) -- 3.14"}},
+	{"SIGN", "SIGN(numeric)", "Returns -1, 0 or 1 depending on the sign of numeric.", []string{"SIGN(-8) -- -1"}},
+	{"SQRT", "SQRT(numeric)", "Returns the square root of numeric.", []string{"SQRT(16) -- 4"}},
+	{"TRUNCATE", "TRUNCATE(numeric1 [, numeric2])", "Truncates numeric1 to numeric2 decimal places.", []string{"TRUNCATE(3.789, 1) -- 3.7"}},
+
+	// String
+	{"CHAR_LENGTH", "CHAR_LENGTH(string)", "Returns the number of characters in string.", []string{"CHAR_LENGTH('calcite') -- 7"}},
+	{"CHARACTER_LENGTH", "CHARACTER_LENGTH(string)", "Same as CHAR_LENGTH(string).", []string{"CHARACTER_LENGTH('calcite') -- 7"}},
+	{"INITCAP", "INITCAP(string)", "Converts the first letter of each word to upper case and the rest to lower case.", []string{"INITCAP('apache calcite') -- Apache Calcite"}},
+	{"LOWER", "LOWER(string)", "Converts string to lower case.", []string{"LOWER('Calcite') -- calcite"}},
+	{"OVERLAY", "OVERLAY(string1 PLACING string2 FROM start [FOR length])", "Replaces length characters of string1 at start with string2.", []string{"OVERLAY('abcdef' PLACING 'XY' FROM 2 FOR 3) -- aXYef"}},
+	{"POSITION", "POSITION(string1 IN string2 [FROM start])", "Returns the position of the first occurrence of string1 in string2, or 0.", []string{"POSITION('c' IN 'calcite') -- 1"}},
+	{"REPLACE", "REPLACE(string, search, replacement)", "Replaces every occurrence of search in string with replacement.", []string{"REPLACE('a-b-c', '-', '+') -- a+b+c"}},
+	{"SUBSTRING", "SUBSTRING(string FROM start [FOR length])", "Returns the part of string starting at start, at most length characters long.", []string{"SUBSTRING('calcite' FROM 2 FOR 3) -- alc"}},
+	{"TRIM", "TRIM([BOTH | LEADING | TRAILING] [characters] FROM string)", "Removes characters, spaces by default, from the start and/or end of string.", []string{"TRIM(BOTH 'x' FROM 'xxcalcitexx') -- calcite", "TRIM('  calcite ')"}},
+	{"UPPER", "UPPER(string)", "Converts string to upper case.", []string{"UPPER('calcite') -- CALCITE"}},
+
+	// Date and time
+	{"CURRENT_DATE", "CURRENT_DATE", "Returns the current date in the session time zone.", []string{"SELECT CURRENT_DATE"}},
+	{"CURRENT_TIME", "CURRENT_TIME", "Returns the current time in the session time zone.", []string{"SELECT CURRENT_TIME"}},
+	{"CURRENT_TIMESTAMP", "CURRENT_TIMESTAMP", "Returns the current timestamp in the session time zone.", []string{"SELECT CURRENT_TIMESTAMP"}},
+	{"EXTRACT", "EXTRACT(timeUnit FROM datetime)", "Returns a field, such as YEAR or HOUR, of a date, time or timestamp.", []string{"EXTRACT(YEAR FROM DATE '2024-05-06') -- 2024"}},
+	{"LOCALTIMESTAMP", "LOCALTIMESTAMP", "Returns the current timestamp without a time zone.", []string{"SELECT LOCALTIMESTAMP"}},
+	{"TIMESTAMPADD", "TIMESTAMPADD(timeUnit, integer, datetime)", "Adds integer timeUnits to datetime.", []string{"TIMESTAMPADD(DAY, 7, ORDER_DATE)"}},
+	{"TIMESTAMPDIFF", "TIMESTAMPDIFF(timeUnit, datetime1, datetime2)", "Returns the number of timeUnits from datetime1 to datetime2.", []string{"TIMESTAMPDIFF(HOUR, START_TIME, END_TIME)"}},
+
+	// Conditional and conversion
+	{"CAST", "CAST(value AS type)", "Converts value to type.", []string{"CAST('42' AS INTEGER)", "CAST(ORDER_TIME AS DATE)"}},
+	{"COALESCE", "COALESCE(value, value [, value]*)", "Returns the first value that is not NULL.", []string{"COALESCE(NICKNAME, NAME, 'unknown')"}},
+	{"NULLIF", "NULLIF(value1, value2)", "Returns NULL if value1 equals value2, otherwise value1.", []string{"NULLIF(DISCOUNT, 0)"}},
+
+	// Aggregate
+	{"AVG", "AVG([ALL | DISTINCT] numeric)", "Returns the average of numeric over the group.", []string{"SELECT DEPTNO, AVG(SAL) FROM EMP GROUP BY DEPTNO"}},
+	{"COLLECT", "COLLECT([ALL | DISTINCT] value)", "Returns a multiset of the values in the group.", []string{"SELECT DEPTNO, COLLECT(ENAME) FROM EMP GROUP BY DEPTNO"}},
+	{"COUNT", "COUNT(*) | COUNT([ALL | DISTINCT] value [, value]*)", "Returns the number of rows, or of rows where value is not NULL.", []string{"SELECT COUNT(*) FROM EMP", "SELECT COUNT(DISTINCT DEPTNO) FROM EMP"}},
+	{"LISTAGG", "LISTAGG([ALL | DISTINCT] value [, separator])", "Concatenates the values of the group into a string.", []string{"SELECT LISTAGG(ENAME, ', ') FROM EMP"}},
+	{"MAX", "MAX([ALL | DISTINCT] value)", "Returns the largest value of the group.", []string{"SELECT MAX(SAL) FROM EMP"}},
+	{"MIN", "MIN([ALL | DISTINCT] value)", "Returns the smallest value of the group.", []string{"SELECT MIN(SAL) FROM EMP"}},
+	{"STDDEV_POP", "STDDEV_POP([ALL | DISTINCT] numeric)", "Returns the population standard deviation of numeric over the group.", []string{"SELECT STDDEV_POP(SAL) FROM EMP"}},
+	{"STDDEV_SAMP", "STDDEV_SAMP([ALL | DISTINCT] numeric)", "Returns the sample standard deviation of numeric over the group.", []string{"SELECT STDDEV_SAMP(SAL) FROM EMP"}},
+	{"SUM", "SUM([ALL | DISTINCT] numeric)", "Returns the sum of numeric over the group.", []string{"SELECT DEPTNO, SUM(SAL) FROM EMP GROUP BY DEPTNO"}},
+	{"VAR_POP", "VAR_POP([ALL | DISTINCT] numeric)", "Returns the population variance of numeric over the group.", []string{"SELECT VAR_POP(SAL) FROM EMP"}},
+	{"VAR_SAMP", "VAR_SAMP([ALL | DISTINCT] numeric)", "Returns the sample variance of numeric over the group.", []string{"SELECT VAR_SAMP(SAL) FROM EMP"}},
+
+	// Window
+	{"DENSE_RANK", "DENSE_RANK() OVER window", "Returns the rank of the row in its partition, without gaps.", []string{"DENSE_RANK() OVER (ORDER BY SAL DESC)"}},
+	{"FIRST_VALUE", "FIRST_VALUE(value) OVER window", "Returns value at the first row of the window frame.", []string{"FIRST_VALUE(ENAME) OVER (PARTITION BY DEPTNO ORDER BY SAL DESC)"}},
+	{"LAG", "LAG(value [, offset [, default]]) OVER window", "Returns value offset rows before the current row.", []string{"LAG(SAL) OVER (ORDER BY HIREDATE)"}},
+	{"LAST_VALUE", "LAST_VALUE(value) OVER window", "Returns value at the last row of the window frame.", []string{"LAST_VALUE(ENAME) OVER (PARTITION BY DEPTNO ORDER BY SAL)"}},
+	{"LEAD", "LEAD(value [, offset [, default]]) OVER window", "Returns value offset rows after the current row.", []string{"LEAD(SAL) OVER (ORDER BY HIREDATE)"}},
+	{"NTILE", "NTILE(buckets) OVER window", "Divides the partition into buckets and returns the bucket of the row.", []string{"NTILE(4) OVER (ORDER BY SAL)"}},
+	{"RANK", "RANK() OVER window", "Returns the rank of the row in its partition, with gaps after ties.", []string{"RANK() OVER (PARTITION BY DEPTNO ORDER BY SAL DESC)"}},
+	{"ROW_NUMBER", "ROW_NUMBER() OVER window", "Numbers the rows of the partition from 1.", []string{"ROW_NUMBER() OVER (PARTITION BY DEPTNO ORDER BY HIREDATE)"}},
+
+	// JSON
+	{"JSON_EXISTS", "JSON_EXISTS(json, path)", "Returns whether path matches a value in json.", []string{"JSON_EXISTS(DOC, '$.address.city')"}},
+	{"JSON_QUERY", "JSON_QUERY(json, path)", "Returns the JSON object or array at path in json.", []string{"JSON_QUERY(DOC, '$.items')"}},
+	{"JSON_VALUE", "JSON_VALUE(json, path [RETURNING type])", "Returns the scalar at path in json.", []string{"JSON_VALUE(DOC, '$.address.city')"}},
+}
+
+// sqlStatements documents the statements and clauses shown by \h
+var sqlStatements = []helpTopic{
+	{"SELECT", "SELECT [ALL | DISTINCT] expression [[AS] alias] [, ...]\n  FROM table [[AS] alias] [, ...]\n  [WHERE condition]\n  [GROUP BY expression [, ...]] [HAVING condition]\n  [ORDER BY expression [ASC | DESC] [, ...]]\n  [LIMIT count] [OFFSET start]",
+		"Retrieves rows from tables.", []string{"SELECT DEPTNO, COUNT(*) FROM EMP WHERE SAL > 1000 GROUP BY DEPTNO ORDER BY 2 DESC"}},
+	{"INSERT", "INSERT INTO table [(column [, ...])] { VALUES (value [, ...]) [, ...] | query }", "Adds rows to a table.", []string{"INSERT INTO DEPT (DEPTNO, DNAME) VALUES (50, 'QA')"}},
+	{"UPSERT", "UPSERT INTO table [(column [, ...])] { VALUES (value [, ...]) | query }", "Inserts rows or updates those with the same primary key (Phoenix).", []string{"UPSERT INTO DEPT VALUES (50, 'QA')"}},
+	{"UPDATE", "UPDATE table SET column = value [, ...] [WHERE condition]", "Changes the rows of a table that match condition.", []string{"UPDATE EMP SET SAL = SAL * 1.1 WHERE DEPTNO = 10"}},
+	{"DELETE", "DELETE FROM table [WHERE condition]", "Removes the rows of a table that match condition.", []string{"DELETE FROM EMP WHERE HIREDATE < DATE '2000-01-01'"}},
+	{"MERGE", "MERGE INTO table USING source ON condition\n  WHEN MATCHED THEN UPDATE SET column = value [, ...]\n  WHEN NOT MATCHED THEN INSERT [(column [, ...])] VALUES (value [, ...])", "Updates matching rows and inserts the others.", []string{"MERGE INTO DEPT D USING NEW_DEPT N ON D.DEPTNO = N.DEPTNO\n  WHEN MATCHED THEN UPDATE SET DNAME = N.DNAME\n  WHEN NOT MATCHED THEN INSERT VALUES (N.DEPTNO, N.DNAME)"}},
+	{"CREATE TABLE", "CREATE TABLE [IF NOT EXISTS] name (column type [NOT NULL] [, ...] [, PRIMARY KEY (column [, ...])])", "Creates a table.", []string{"CREATE TABLE DEPT (DEPTNO INTEGER NOT NULL PRIMARY KEY, DNAME VARCHAR(20))"}},
+	{"CREATE VIEW", "CREATE [OR REPLACE] VIEW name [(column [, ...])] AS query", "Creates a view over a query.", []string{"CREATE VIEW RICH AS SELECT * FROM EMP WHERE SAL > 5000"}},
+	{"DROP", "DROP { TABLE | VIEW | SCHEMA } [IF EXISTS] name", "Removes a table, view or schema.", []string{"DROP TABLE IF EXISTS TMP_EMP"}},
+	{"ALTER", "ALTER TABLE name { ADD column type | DROP COLUMN column }", "Changes the columns of a table.", []string{"ALTER TABLE EMP ADD BONUS DECIMAL(10, 2)"}},
+	{"EXPLAIN", "EXPLAIN PLAN [WITH TYPE | WITHOUT IMPLEMENTATION] FOR query", "Shows the plan of a query without running it.", []string{"EXPLAIN PLAN FOR SELECT * FROM EMP WHERE DEPTNO = 10"}},
+	{"WITH", "WITH name [(column [, ...])] AS (query) [, ...] query", "Names subqueries for use in the main query.", []string{"WITH TOP AS (SELECT * FROM EMP ORDER BY SAL DESC LIMIT 5) SELECT ENAME FROM TOP"}},
+	{"VALUES", "VALUES (value [, ...]) [, ...]", "Returns rows of literal values.", []string{"VALUES (1, 'a'), (2, 'b')"}},
+	{"JOIN", "table [INNER | LEFT [OUTER] | RIGHT [OUTER] | FULL [OUTER] | CROSS] JOIN table [ON condition | USING (column [, ...])]", "Combines the rows of two tables.", []string{"SELECT E.ENAME, D.DNAME FROM EMP E JOIN DEPT D ON E.DEPTNO = D.DEPTNO"}},
+	{"CASE", "CASE [value] WHEN condition THEN result [...] [ELSE result] END", "Returns the result of the first condition that holds.", []string{"CASE WHEN SAL > 3000 THEN 'high' ELSE 'normal' END"}},
+}
+
+// functionIndex finds catalogued functions by name. It is built as a
+// variable rather than in init so that the dialects can rely on it.
+var functionIndex = func() map[string]helpTopic {
+	index := map[string]helpTopic{}
+	for _, f := range sqlFunctions {
+		index[f.name] = f
+	}
+	return index
+}()
+
+// withSignatures returns keywords with the description of catalogued
+// functions replaced by their signature, adding catalogued functions
+// missing from keywords
+func withSignatures(keywords []prompt.Suggest) []prompt.Suggest {
+	suggestions := make([]prompt.Suggest, 0, len(keywords))
+	seen := map[string]bool{}
+	for _, k := range keywords {
+		if f, ok := functionIndex[k.Text]; ok {
+			k.Description = f.syntax
+		}
+		seen[k.Text] = true
+		suggestions = append(suggestions, k)
+	}
+	for _, f := range sqlFunctions {
+		if !seen[f.name] {
+			suggestions = append(suggestions, prompt.Suggest{Text: f.name, Description: f.syntax})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Text < suggestions[j].Text
+	})
+	return suggestions
+}
+
+// serverFunctionSuggestions returns the functions reported by the server
+// that are not already among keywords
+func serverFunctionSuggestions(meta *metadata, keywords []prompt.Suggest) []prompt.Suggest {
+	if meta == nil || len(meta.functions) == 0 {
+		return nil
+	}
+	known := make(map[string]bool, len(keywords))
+	for _, k := range keywords {
+		known[strings.ToUpper(k.Text)] = true
+	}
+	var suggestions []prompt.Suggest
+	for _, name := range meta.functions {
+		if !known[strings.ToUpper(name)] {
+			known[strings.ToUpper(name)] = true
+			suggestions = append(suggestions, prompt.Suggest{Text: name, Description: "Server function"})
+		}
+	}
+	return suggestions
+}
+
+// printHelpTopic writes the help for a statement, clause or function
+func printHelpTopic(w io.Writer, t helpTopic) {
+	fmt.Fprintf(w, "%s\n  %s\n\nSyntax:\n  %s\n", t.name, t.description, t.syntax)
+	if len(t.examples) > 0 {
+		fmt.Fprintln(w, "\nExamples:")
+		for _, example := range t.examples {
+			fmt.Fprintf(w, "  %s\n", example)
+		}
+	}
+}
+
+// sqlHelp prints \h: the topics when topic is empty, otherwise the help
+// for the statement, clause, function or keyword it names
+func (s *PromptSession) sqlHelp(w io.Writer, topic string) error {
+	topic = strings.ToUpper(strings.Join(strings.Fields(topic), " "))
+	if topic == "" {
+		fmt.Fprintln(w, "Statements and clauses:")
+		for _, t := range sqlStatements {
+			fmt.Fprintf(w, "  %s\n", t.name)
+		}
+		var names []string
+		for _, f := range sqlFunctions {
+			names = append(names, f.name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(w, "\nFunctions:\n  %s\n\nType \\h <name> for its syntax and examples.\n", strings.Join(names, ", "))
+		return nil
+	}
+
+	for _, t := range sqlStatements {
+		if t.name == topic {
+			printHelpTopic(w, t)
+			return nil
+		}
+	}
+	if f, ok := functionIndex[topic]; ok {
+		printHelpTopic(w, f)
+		return nil
+	}
+	// Statements such as CREATE VIEW are found by their first word too
+	for _, t := range sqlStatements {
+		if strings.HasPrefix(t.name, topic+" ") {
+			printHelpTopic(w, t)
+			return nil
+		}
+	}
+	meta := s.catalog.snapshot()
+	keywords := s.sqlKeywords(meta)
+	for _, k := range append(keywords[:len(keywords):len(keywords)], serverFunctionSuggestions(meta, keywords)...) {
+		if strings.EqualFold(k.Text, topic) {
+			fmt.Fprintf(w, "%s\n  %s\n", k.Text, k.Description)
+			return nil
+		}
+	}
+	return fmt.Errorf("no help for %s", topic)
+}
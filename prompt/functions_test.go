@@ -0,0 +1,93 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/c-bata/go-prompt"
+)
+
+func TestWithSignatures(t *testing.T) {
+	keywords := withSignatures([]prompt.Suggest{
+		{Text: "SELECT", Description: "Retrieve data"},
+		{Text: "SUBSTRING", Description: "Function"},
+	})
+	descriptions := map[string]string{}
+	for i, k := range keywords {
+		descriptions[k.Text] = k.Description
+		if i > 0 && keywords[i-1].Text > k.Text {
+			t.Errorf("Expected keywords in alphabetical order, got %s before %s", keywords[i-1].Text, k.Text)
+		}
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"SELECT", "Retrieve data"},
+		{"SUBSTRING", "SUBSTRING(string FROM start [FOR length])"},
+		// Catalogued functions missing from the keywords are added
+		{"LISTAGG", "LISTAGG([ALL | DISTINCT] value [, separator])"},
+	}
+	for _, tt := range tests {
+		if got := descriptions[tt.name]; got != tt.want {
+			t.Errorf("Expected %s to be described as %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestSQLHelp(t *testing.T) {
+	session := &PromptSession{
+		keywords: withSignatures([]prompt.Suggest{{Text: "NATURAL", Description: "Natural join"}}),
+		catalog:  newMetadataCache(nil),
+	}
+	session.catalog.meta = &metadata{functions: []string{"MY_REVERSE"}}
+
+	tests := []struct {
+		topic string
+		want  []string
+	}{
+		{"", []string{"Statements and clauses:", "  CREATE TABLE", "SUBSTRING"}},
+		{"substring", []string{"SUBSTRING(string FROM start [FOR length])", "Examples:", "SUBSTRING('calcite' FROM 2 FOR 3)"}},
+		{"create  view", []string{"CREATE [OR REPLACE] VIEW"}},
+		{"CREATE", []string{"CREATE TABLE [IF NOT EXISTS]"}},
+		{"natural", []string{"NATURAL\n  Natural join"}},
+		{"my_reverse", []string{"MY_REVERSE\n  Server function"}},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := session.sqlHelp(&out, tt.topic); err != nil {
+			t.Errorf("Unexpected error for %q: %s", tt.topic, err)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("Expected help for %q to contain %q, got:\n%s", tt.topic, want, out.String())
+			}
+		}
+	}
+
+	if err := session.sqlHelp(&bytes.Buffer{}, "frobnicate"); err == nil || err.Error() != "no help for FROBNICATE" {
+		t.Errorf("Expected no help for an unknown topic, got %v", err)
+	}
+}
+
+func TestServerFunctionCompletion(t *testing.T) {
+	session := &PromptSession{
+		keywords: withSignatures([]prompt.Suggest{{Text: "SELECT"}}),
+		catalog:  newMetadataCache(nil),
+		match:    MatchPrefix,
+	}
+	session.catalog.meta = &metadata{functions: []string{"MY_REVERSE", "MOD"}}
+
+	got := session.completer(completionDocument("SELECT M", ""))
+	var texts []string
+	for _, s := range got {
+		texts = append(texts, s.Text+": "+s.Description)
+	}
+	want := []string{"MAX: MAX([ALL | DISTINCT] value)", "MIN: MIN([ALL | DISTINCT] value)", "MOD: MOD(numeric1, numeric2)", "MY_REVERSE: Server function"}
+	if strings.Join(texts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected %v, got %v", want, texts)
+	}
+}
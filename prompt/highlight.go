@@ -0,0 +1,211 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/satyakommula96/calcite-cli/calcitesql"
+)
+
+// highlightColors are the colours of each kind of token in the input line
+type highlightColors struct {
+	keyword    prompt.Color
+	identifier prompt.Color
+	str        prompt.Color
+	number     prompt.Color
+	comment    prompt.Color
+	operator   prompt.Color
+	// unmatched marks unterminated strings, quoted identifiers and comments,
+	// and parentheses without a partner
+	unmatched prompt.Color
+}
+
+// highlightSpan is a part of the input shown in one colour
+type highlightSpan struct {
+	start, end int
+	color      prompt.Color
+	bold       bool
+}
+
+// highlightSQL colours sql with the lexer used to split statements, so the
+// colours agree with how the statement will be read
+func highlightSQL(sql string, colors highlightColors, isKeyword func(string) bool) []highlightSpan {
+	tokens := calcitesql.Tokenize(sql)
+
+	// Parentheses are matched over the whole text
+	unmatched := map[int]bool{}
+	var open []int
+	for i, tok := range tokens {
+		switch {
+		case tok.Kind != calcitesql.TokenPunct:
+		case tok.Text == "(":
+			open = append(open, i)
+		case tok.Text == ")" && len(open) > 0:
+			open = open[:len(open)-1]
+		case tok.Text == ")":
+			unmatched[i] = true
+		}
+	}
+	for _, i := range open {
+		unmatched[i] = true
+	}
+
+	spans := make([]highlightSpan, 0, len(tokens))
+	for i, tok := range tokens {
+		span := highlightSpan{start: tok.Pos, end: tok.Pos + len(tok.Text), color: prompt.DefaultColor}
+		switch tok.Kind {
+		case calcitesql.TokenWord:
+			span.color = colors.identifier
+			if isKeyword(tok.Text) {
+				span.color, span.bold = colors.keyword, true
+			}
+		case calcitesql.TokenQuotedIdent:
+			span.color = colors.identifier
+		case calcitesql.TokenString:
+			span.color = colors.str
+		case calcitesql.TokenNumber:
+			span.color = colors.number
+		case calcitesql.TokenComment:
+			span.color = colors.comment
+		case calcitesql.TokenPunct, calcitesql.TokenOperator:
+			span.color = colors.operator
+		}
+		if tok.Unterminated || unmatched[i] {
+			span.color, span.bold = colors.unmatched, true
+		}
+		// Without colours, as in the mono theme, nothing stands out
+		span.bold = span.bold && span.color != prompt.DefaultColor
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// highlightWriter colours the input line, which go-prompt writes in a single
+// colour. It wraps the terminal writer and takes the text written after the
+// prompt prefix, up to the next cursor movement, to be the input.
+//
+// This follows how Render and BreakLine of the go-prompt fork pinned in
+// go.mod draw the input: they erase below the cursor, write the prefix in
+// one piece, then the input, and move the cursor before writing anything
+// else. TestHighlightWriterRender checks this against the fork.
+type highlightWriter struct {
+	prompt.ConsoleWriter
+	session *PromptSession
+	colors  highlightColors
+	// prefix is the prompt prefix last handed to go-prompt
+	prefix string
+	// armed is set by erasing below the cursor, which go-prompt does right
+	// before writing the prefix, so other text equal to it is not mistaken
+	// for the prefix
+	armed   bool
+	inInput bool
+	// reverse is set while go-prompt draws the cursor of a multi-line input
+	reverse bool
+	// written is the input written so far in this rendering
+	written string
+}
+
+// livePrefix wraps the session prefix to recognise it when it is written
+func (w *highlightWriter) livePrefix() (string, bool) {
+	prefix, ok := w.session.LivePrefix()
+	w.prefix = prefix
+	return prefix, ok
+}
+
+func (w *highlightWriter) WriteStr(data string) {
+	if !w.inInput {
+		w.ConsoleWriter.WriteStr(data)
+		if w.armed && data == w.prefix {
+			w.inInput, w.reverse, w.written = true, false, ""
+		}
+		w.armed = false
+		return
+	}
+
+	// Earlier lines of a multi-line statement are the context of this one
+	context := ""
+	if w.session.isMultiline {
+		context = w.session.multiLineQuery.String()
+	}
+	text := context + w.written + data
+	start := len(context) + len(w.written)
+	w.written += data
+	if !w.session.highlight || w.reverse || strings.HasPrefix(strings.TrimSpace(text), `\`) {
+		w.ConsoleWriter.WriteStr(data)
+		return
+	}
+
+	w.session.sqlKeywords(w.session.catalog.snapshot())
+	for _, span := range highlightSQL(text, w.colors, w.session.isKeyword) {
+		if span.end <= start {
+			continue
+		}
+		w.ConsoleWriter.SetColor(span.color, prompt.DefaultColor, span.bold)
+		w.ConsoleWriter.WriteStr(text[max(span.start, start):span.end])
+	}
+	w.ConsoleWriter.SetColor(prompt.DefaultColor, prompt.DefaultColor, false)
+}
+
+func (w *highlightWriter) EraseDown() {
+	w.inInput = false
+	w.armed = true
+	w.ConsoleWriter.EraseDown()
+}
+
+func (w *highlightWriter) SetDisplayAttributes(fg, bg prompt.Color, attrs ...prompt.DisplayAttribute) {
+	for _, attr := range attrs {
+		if attr == prompt.DisplayReverse {
+			w.reverse = true
+		}
+	}
+	w.ConsoleWriter.SetDisplayAttributes(fg, bg, attrs...)
+}
+
+func (w *highlightWriter) SetColor(fg, bg prompt.Color, bold bool) {
+	w.reverse = false
+	w.ConsoleWriter.SetColor(fg, bg, bold)
+}
+
+// Moving the cursor ends the input
+
+func (w *highlightWriter) CursorGoTo(row, col int) {
+	w.inInput = false
+	w.ConsoleWriter.CursorGoTo(row, col)
+}
+
+func (w *highlightWriter) CursorUp(n int) {
+	w.inInput = false
+	w.ConsoleWriter.CursorUp(n)
+}
+
+func (w *highlightWriter) CursorDown(n int) {
+	w.inInput = false
+	w.ConsoleWriter.CursorDown(n)
+}
+
+func (w *highlightWriter) CursorForward(n int) {
+	w.inInput = false
+	w.ConsoleWriter.CursorForward(n)
+}
+
+func (w *highlightWriter) CursorBackward(n int) {
+	w.inInput = false
+	w.ConsoleWriter.CursorBackward(n)
+}
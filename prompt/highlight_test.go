@@ -0,0 +1,170 @@
+package prompt
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/c-bata/go-prompt"
+)
+
+func TestHighlightSQL(t *testing.T) {
+	colors := highlightColors{
+		keyword:    prompt.Blue,
+		identifier: prompt.Turquoise,
+		str:        prompt.Green,
+		number:     prompt.Fuchsia,
+		comment:    prompt.DarkGray,
+		operator:   prompt.DefaultColor,
+		unmatched:  prompt.Red,
+	}
+	isKeyword := func(word string) bool {
+		return strings.EqualFold(word, "SELECT") || strings.EqualFold(word, "FROM") || strings.EqualFold(word, "COUNT")
+	}
+
+	tests := []struct {
+		sql  string
+		want []string
+	}{
+		{"select id", []string{"select:Blue!", " :DefaultColor", "id:Turquoise"}},
+		{`'a' 1.5 "x"`, []string{"'a':Green", " :DefaultColor", "1.5:Fuchsia", " :DefaultColor", `"x":Turquoise`}},
+		{"-- note", []string{"-- note:DarkGray"}},
+		{"'open", []string{"'open:Red!"}},
+		{"count(x))", []string{"count:Blue!", "(:DefaultColor", "x:Turquoise", "):DefaultColor", "):Red!"}},
+		{"count((x)", []string{"count:Blue!", "(:Red!", "(:DefaultColor", "x:Turquoise", "):DefaultColor"}},
+	}
+	names := map[prompt.Color]string{prompt.Blue: "Blue", prompt.Turquoise: "Turquoise", prompt.Green: "Green",
+		prompt.Fuchsia: "Fuchsia", prompt.DarkGray: "DarkGray", prompt.DefaultColor: "DefaultColor", prompt.Red: "Red"}
+	for _, tt := range tests {
+		var got []string
+		for _, span := range highlightSQL(tt.sql, colors, isKeyword) {
+			text := tt.sql[span.start:span.end] + ":" + names[span.color]
+			if span.bold {
+				text += "!"
+			}
+			got = append(got, text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("highlightSQL(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
+
+// recordingWriter records the text written and the colour it was written in
+type recordingWriter struct {
+	prompt.ConsoleWriter
+	color prompt.Color
+	out   strings.Builder
+}
+
+func (w *recordingWriter) WriteStr(data string) {
+	if data != "" {
+		fmt.Fprintf(&w.out, "[%d]%s", w.color, data)
+	}
+}
+
+func (w *recordingWriter) SetColor(fg, bg prompt.Color, bold bool) { w.color = fg }
+
+func (w *recordingWriter) SetDisplayAttributes(fg, bg prompt.Color, attrs ...prompt.DisplayAttribute) {
+	w.color = fg
+}
+
+func (w *recordingWriter) CursorBackward(n int) {}
+
+func (w *recordingWriter) EraseDown() {}
+
+func (w *recordingWriter) Flush() error { return nil }
+
+func TestHighlightWriter(t *testing.T) {
+	session := &PromptSession{catalog: newMetadataCache(nil), highlight: true}
+	session.setDialect(DialectCalcite)
+	out := &recordingWriter{}
+	w := &highlightWriter{ConsoleWriter: out, session: session, colors: highlightColors{keyword: prompt.Blue, str: prompt.Green, unmatched: prompt.Red}}
+	render := func(parts ...string) string {
+		out.out.Reset()
+		prefix, _ := w.livePrefix()
+		w.EraseDown()
+		w.SetColor(prompt.Yellow, prompt.DefaultColor, false)
+		w.WriteStr(prefix)
+		for _, part := range parts {
+			w.WriteStr(part)
+		}
+		w.CursorBackward(0)
+		// Text written after the cursor moves is not input
+		w.WriteStr("SELECT")
+		return strings.TrimPrefix(out.out.String(), fmt.Sprintf("[%d]%s", prompt.Yellow, prefix))
+	}
+	code := func(c prompt.Color) string { return fmt.Sprintf("[%d]", c) }
+
+	want := code(prompt.Blue) + "SELECT" + code(prompt.DefaultColor) + " " + code(prompt.Green) + "'a'" + code(prompt.DefaultColor) + "SELECT"
+	if got := render("SELECT 'a'"); got != want {
+		t.Errorf("Expected a highlighted input line, got %q", got)
+	}
+
+	// Later parts continue the earlier ones, which cannot see ahead
+	want = code(prompt.Red) + "'a" + code(prompt.Green) + "b'" + code(prompt.DefaultColor) + "SELECT"
+	if got := render("'a", "b'"); got != want {
+		t.Errorf("Expected the parts to form one string, got %q", got)
+	}
+
+	// Earlier lines of a statement carry an open string over
+	session.isMultiline = true
+	session.multiLineQuery.WriteString("SELECT 'a ")
+	want = code(prompt.Green) + "b'" + code(prompt.DefaultColor) + "SELECT"
+	if got := render("b'"); got != want {
+		t.Errorf("Expected the string opened on an earlier line to be closed, got %q", got)
+	}
+	session.isMultiline = false
+
+	// Backslash commands and a disabled setting are left alone
+	if got := render(`\d orders`); got != code(prompt.Yellow)+`\d orders`+code(prompt.Yellow)+"SELECT" {
+		t.Errorf("Expected a backslash command to be written unchanged, got %q", got)
+	}
+	session.highlight = false
+	if got := render("SELECT"); got != code(prompt.Yellow)+"SELECT"+code(prompt.Yellow)+"SELECT" {
+		t.Errorf("Expected no highlighting when disabled, got %q", got)
+	}
+}
+
+// setRenderField sets an unexported field of a go-prompt renderer
+func setRenderField(r *prompt.Render, name string, value interface{}) {
+	field := reflect.ValueOf(r).Elem().FieldByName(name)
+	reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Set(reflect.ValueOf(value))
+}
+
+func TestHighlightWriterRender(t *testing.T) {
+	session := &PromptSession{catalog: newMetadataCache(nil), highlight: true}
+	session.setDialect(DialectCalcite)
+	out := &recordingWriter{ConsoleWriter: prompt.NewStdoutWriter()}
+	w := &highlightWriter{ConsoleWriter: out, session: session, colors: highlightColors{keyword: prompt.Blue, str: prompt.Green, unmatched: prompt.Red}}
+
+	// Drive the renderer of the pinned go-prompt fork, with a completion
+	// menu holding the prefix and a keyword that must not be highlighted
+	r := &prompt.Render{}
+	setRenderField(r, "out", prompt.ConsoleWriter(w))
+	setRenderField(r, "livePrefixCallback", w.livePrefix)
+	r.UpdateWinSize(&prompt.WinSize{Row: 24, Col: 80})
+	prefix, _ := session.LivePrefix()
+	completion := prompt.NewCompletionManager(func(prompt.Document) []prompt.Suggest {
+		return []prompt.Suggest{{Text: prefix}, {Text: "SELECT"}}
+	}, 6)
+	buf := prompt.NewBuffer()
+	buf.InsertText("SELECT 'a' FROM t", false, true)
+	completion.Update(*buf.Document())
+
+	r.Render(buf, "", completion)
+	if got := strings.Count(out.out.String(), fmt.Sprintf("[%d]SELECT", prompt.Blue)); got != 1 {
+		t.Errorf("Expected the input and not the menu to be highlighted, got %q", out.out.String())
+	}
+	if !strings.Contains(out.out.String(), fmt.Sprintf("[%d]'a'", prompt.Green)) {
+		t.Errorf("Expected the string to be highlighted, got %q", out.out.String())
+	}
+
+	out.out.Reset()
+	r.BreakLine(buf)
+	if !strings.HasPrefix(strings.TrimPrefix(out.out.String(), fmt.Sprintf("[%d]%s", prompt.DefaultColor, prefix)), fmt.Sprintf("[%d]SELECT", prompt.Blue)) {
+		t.Errorf("Expected the accepted line to be highlighted, got %q", out.out.String())
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+)
+
+// Keyword cases used when inserting keywords and functions
+const (
+	KeywordUpper = "upper"
+	KeywordLower = "lower"
+)
+
+// KeywordCases lists the accepted keyword cases
+var KeywordCases = []string{KeywordUpper, KeywordLower}
+
+// reservedWords cannot be used as unquoted identifiers in Calcite
+var reservedWords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`
+		ALL ALLOCATE ALTER AND ANY ARE ARRAY AS ASYMMETRIC AT AUTHORIZATION
+		BEGIN BETWEEN BOTH BY CALL CASE CAST CHECK CLOSE COLLATE COLUMN COMMIT
+		CONDITION CONNECT CONSTRAINT CREATE CROSS CUBE CURRENT CURRENT_DATE
+		CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER CURSOR DATE DAY DECLARE
+		DEFAULT DELETE DESCRIBE DISTINCT DROP ELSE END ESCAPE EXCEPT EXEC
+		EXISTS EXPLAIN EXTRACT FALSE FETCH FILTER FOR FOREIGN FROM FULL
+		FUNCTION GRANT GROUP HAVING HOUR IN INNER INSERT INTERSECT INTERVAL
+		INTO IS JOIN LATERAL LEADING LEFT LIKE LIMIT LOCAL MATCH MERGE MINUS
+		MINUTE MONTH NATURAL NEW NOT NULL OF OFFSET OLD ON ONLY OPEN OR ORDER
+		OUTER OVER PARTITION PRIMARY RANGE REFERENCES RIGHT ROLLBACK ROLLUP
+		ROW ROWS SECOND SELECT SESSION_USER SET SOME SYMMETRIC SYSTEM
+		SYSTEM_USER TABLE THEN TIME TIMESTAMP TO TRAILING TRUE UNION UNIQUE
+		UNKNOWN UPDATE UPSERT USER USING VALUE VALUES WHEN WHERE WINDOW WITH
+		YEAR`) {
+		reservedWords[word] = true
+	}
+}
+
+// needsQuotes reports whether name has to be written as a quoted identifier:
+// unquoted identifiers are converted to upper case, so anything but an upper
+// case name made of letters, digits, _ and $ would refer to another object
+func needsQuotes(name string) bool {
+	if name == "" || reservedWords[name] {
+		return true
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '_' || r == '$'):
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// quoteIdentifier returns name as it has to be written in a statement
+func quoteIdentifier(name string) string {
+	if !needsQuotes(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// insertStyle decides how completions are written into the input line.
+// The zero value inserts them unchanged.
+type insertStyle struct {
+	// quoteNames quotes table, schema and column names when needed
+	quoteNames bool
+	// inQuotes is set when completing after an opening double quote. The
+	// name is then closed with a quote, and only the part of it after the
+	// last word separator typed is replaced.
+	inQuotes bool
+	typed    string
+	// keywordCase is KeywordUpper or KeywordLower, or empty to keep keywords
+	// as they are
+	keywordCase string
+}
+
+// apply returns the suggestion to insert for a name or keyword
+func (st insertStyle) apply(s prompt.Suggest, keyword bool) prompt.Suggest {
+	switch {
+	case keyword && st.keywordCase == KeywordLower:
+		s.Text = strings.ToLower(s.Text)
+	case keyword && st.keywordCase == KeywordUpper:
+		s.Text = strings.ToUpper(s.Text)
+	case !keyword && st.inQuotes:
+		text := strings.ReplaceAll(s.Text, `"`, `""`) + `"`
+		// go-prompt replaces the text after the last separator, which may
+		// be inside the quotes, as in "big ord
+		if i := strings.LastIndexAny(st.typed, completionWordSeparator); i >= 0 && i+1 <= len(text) {
+			text = text[i+1:]
+		}
+		s.Text = text
+	case !keyword && st.quoteNames:
+		s.Text = quoteIdentifier(s.Text)
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/c-bata/go-prompt"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"ORDERS", "ORDERS"},
+		{"ORDER_ID2", "ORDER_ID2"},
+		{"orders", `"orders"`},
+		{"Orders", `"Orders"`},
+		{"ORDER", `"ORDER"`},
+		{"2024_SALES", `"2024_SALES"`},
+		{"BIG ORDERS", `"BIG ORDERS"`},
+		{`SAY "HI"`, `"SAY ""HI"""`},
+	}
+	for _, tt := range tests {
+		if got := quoteIdentifier(tt.name); got != tt.want {
+			t.Errorf("quoteIdentifier(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPromptSessionCompleterQuoting(t *testing.T) {
+	session := &PromptSession{
+		keywords: []prompt.Suggest{{Text: "ORDER"}, {Text: "OR"}},
+		catalog:  newMetadataCache(nil),
+		match:    MatchPrefix,
+	}
+	meta := session.catalog.snapshot()
+	meta.addTable("", "sales", "orders").columns = columnsNamed("id", "Order Total", "GROUP")
+	meta.addTable("", "sales", "big orders").columns = columnsNamed("ID")
+
+	tests := []struct {
+		name        string
+		input       string
+		after       string
+		keywordCase string
+		want        []string
+	}{
+		{name: "lower case table", input: "SELECT * FROM ord", want: []string{`"orders"`}},
+		{name: "quoted schema", input: `SELECT * FROM "sales".`, want: []string{`"orders"`, `"big orders"`}},
+		{name: "opened quote", input: `SELECT * FROM "sales"."or`, want: []string{`orders"`}},
+		{name: "space in quotes", input: `SELECT * FROM "sales"."big or`, want: []string{`orders"`}},
+		{name: "case in quotes", input: `SELECT * FROM "sales"."BIG or`, want: []string{}},
+		{name: "columns", input: "SELECT o.", after: ` FROM "sales"."orders" o`, want: []string{`"id"`, `"Order Total"`, `"GROUP"`}},
+		{name: "keywords kept", input: "SELECT * FROM t or", want: []string{"ORDER", "OR"}},
+		{name: "lower keywords", input: "SELECT * FROM t or", keywordCase: KeywordLower, want: []string{"order", "or"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session.keywordCase = tt.keywordCase
+			got := []string{}
+			for _, s := range session.completer(completionDocument(tt.input, tt.after)) {
+				got = append(got, s.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("completer(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
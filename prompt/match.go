@@ -0,0 +1,166 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+)
+
+// Completion matching modes
+const (
+	MatchFuzzy  = "fuzzy"
+	MatchPrefix = "prefix"
+)
+
+// MatchModes lists the accepted completion matching modes
+var MatchModes = []string{MatchFuzzy, MatchPrefix}
+
+// How well a candidate matches the typed word, best first
+const (
+	matchNone = iota
+	matchStart
+	matchSegments
+	matchSubsequence
+)
+
+// matchWord reports how candidate matches word, ignoring case, and a score
+// ordering candidates of the same kind of match, lower is better
+func matchWord(candidate, word string, fuzzy bool) (kind, score int) {
+	c, w := strings.ToUpper(candidate), strings.ToUpper(word)
+	if strings.HasPrefix(c, w) {
+		return matchStart, 0
+	}
+	if !fuzzy {
+		return matchNone, 0
+	}
+	if strings.Contains(w, "_") {
+		// Underscores separate abbreviated parts of the name
+		if matchSegmentPrefixes(c, w) {
+			return matchSegments, 0
+		}
+		return matchNone, 0
+	}
+	if score, ok := subsequenceScore(c, w); ok {
+		return matchSubsequence, score
+	}
+	return matchNone, 0
+}
+
+// matchSegmentPrefixes reports whether each underscore separated part of
+// word starts a part of candidate, in order and beginning with the first,
+// so that c_i matches CUSTOMER_ID and CUSTOMER_ORDER_ID
+func matchSegmentPrefixes(candidate, word string) bool {
+	parts := strings.Split(candidate, "_")
+	i := 0
+	for n, segment := range strings.Split(word, "_") {
+		for i < len(parts) && !strings.HasPrefix(parts[i], segment) {
+			if n == 0 {
+				return false
+			}
+			i++
+		}
+		if i == len(parts) {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// subsequenceScore reports whether the letters of word appear in candidate
+// in order, as custid in CUSTOMER_ID. The score prefers candidates starting
+// with the same letter, then those where the letters are closest together.
+func subsequenceScore(candidate, word string) (int, bool) {
+	first, last := -1, -1
+	i := 0
+	for j := 0; j < len(candidate) && i < len(word); j++ {
+		if candidate[j] == word[i] {
+			if first < 0 {
+				first = j
+			}
+			last = j
+			i++
+		}
+	}
+	if i < len(word) {
+		return 0, false
+	}
+	score := last - first
+	if first > 0 {
+		score += len(candidate)
+	}
+	return score, true
+}
+
+// rankSuggestions filters names (tables, schemas and columns in scope) and
+// keywords (keywords and functions) by the typed word. Everything the word
+// starts comes first, then the fuzzy matches of names and finally those of
+// keywords, each group keeping closer matches and then the given order first.
+// The suggestions are written as style asks for.
+func rankSuggestions(names, keywords []prompt.Suggest, word string, fuzzy bool, style insertStyle) []prompt.Suggest {
+	type ranked struct {
+		suggestion prompt.Suggest
+		keyword    bool
+		group      int
+		kind       int
+		score      int
+	}
+	var matches []ranked
+	add := func(candidates []prompt.Suggest, keyword bool) {
+		for _, candidate := range candidates {
+			// Quoted names are case sensitive, and the typed part of the
+			// name stays in the input
+			if style.inQuotes && !strings.HasPrefix(candidate.Text, word) {
+				continue
+			}
+			kind, score := matchWord(candidate.Text, word, fuzzy)
+			if kind == matchNone {
+				continue
+			}
+			group := 0
+			if kind != matchStart {
+				group = 1
+				if keyword {
+					group = 2
+				}
+			}
+			matches = append(matches, ranked{candidate, keyword, group, kind, score})
+		}
+	}
+	add(names, false)
+	add(keywords, true)
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.group != b.group {
+			return a.group < b.group
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		return a.score < b.score
+	})
+	suggestions := make([]prompt.Suggest, len(matches))
+	for i, m := range matches {
+		suggestions[i] = style.apply(m.suggestion, m.keyword)
+	}
+	return suggestions
+}
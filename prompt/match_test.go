@@ -0,0 +1,67 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/c-bata/go-prompt"
+)
+
+func TestMatchWord(t *testing.T) {
+	tests := []struct {
+		candidate string
+		word      string
+		fuzzy     bool
+		want      int
+	}{
+		{"CUSTOMER_ID", "cust", true, matchStart},
+		{"CUSTOMER_ID", "cust", false, matchStart},
+		{"CUSTOMER_ID", "c_i", true, matchSegments},
+		{"CUSTOMER_ORDER_ID", "c_i", true, matchSegments},
+		{"CUSTOMER_ID", "o_i", true, matchNone},
+		{"CUSTOMER_ID", "custid", true, matchSubsequence},
+		{"CUSTOMER_ID", "custid", false, matchNone},
+		{"CUSTOMER_ID", "idc", true, matchNone},
+	}
+	for _, tt := range tests {
+		if got, _ := matchWord(tt.candidate, tt.word, tt.fuzzy); got != tt.want {
+			t.Errorf("matchWord(%q, %q, %v) = %d, want %d", tt.candidate, tt.word, tt.fuzzy, got, tt.want)
+		}
+	}
+}
+
+func TestRankSuggestions(t *testing.T) {
+	suggest := func(texts ...string) []prompt.Suggest {
+		var suggestions []prompt.Suggest
+		for _, text := range texts {
+			suggestions = append(suggestions, prompt.Suggest{Text: text})
+		}
+		return suggestions
+	}
+	names := suggest("CUSTOMER_ID", "ACCOUNT_ID", "COUNTRY", "CUSTOMER_NAME")
+	keywords := suggest("COUNT", "CURRENT_DATE", "CAST")
+
+	tests := []struct {
+		word  string
+		fuzzy bool
+		want  []string
+	}{
+		// Prefix matches first, names before keywords
+		{"cou", true, []string{"COUNTRY", "COUNT", "ACCOUNT_ID"}},
+		{"cou", false, []string{"COUNTRY", "COUNT"}},
+		{"c_i", true, []string{"CUSTOMER_ID"}},
+		// Fuzzy matches of names before keywords, closest first
+		{"cid", true, []string{"CUSTOMER_ID", "ACCOUNT_ID"}},
+		{"custid", true, []string{"CUSTOMER_ID"}},
+		{"ct", true, []string{"CUSTOMER_ID", "CUSTOMER_NAME", "COUNTRY", "ACCOUNT_ID", "CAST", "COUNT", "CURRENT_DATE"}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, s := range rankSuggestions(names, keywords, tt.word, tt.fuzzy, insertStyle{}) {
+			got = append(got, s.Text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("rankSuggestions(%q, fuzzy %v) = %v, want %v", tt.word, tt.fuzzy, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"context"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// schemaKeywords start statements after which metadata is refreshed
+var schemaKeywords = map[string]bool{"CREATE": true, "DROP": true, "ALTER": true}
+
+// metadataCache loads metadata in the background so the prompt never waits
+// for it. Tables are loaded up front and the columns of a schema when one of
+// its tables is first referenced.
+//
+// Loaded metadata is published as a new snapshot and never modified
+// afterwards, so readers can use a snapshot without holding the lock.
+type metadataCache struct {
+	// source is nil when nothing can be loaded, as in tests
+	source *metadataSource
+	// path is the file the metadata is kept in between sessions, empty to
+	// keep it in memory only
+	path string
+	// ttl is how long a saved file is used without reloading it
+	ttl time.Duration
+
+	mu   sync.Mutex
+	meta *metadata
+	// generation changes whenever the metadata is refreshed or replaced,
+	// so that loads started before are discarded
+	generation int
+	// columns holds the schemas whose columns are being loaded (false) or
+	// loaded (true)
+	columns map[[2]string]bool
+	loading sync.WaitGroup
+	// version counts published snapshots, so that only the latest is saved
+	version int
+
+	saveMu       sync.Mutex
+	savedVersion int
+}
+
+func newMetadataCache(source *metadataSource) *metadataCache {
+	return &metadataCache{source: source, meta: &metadata{}}
+}
+
+// snapshot returns the metadata loaded so far
+func (c *metadataCache) snapshot() *metadata {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.meta
+}
+
+// start loads the metadata saved by an earlier session, if any, and
+// reloads it in the background when it is missing or older than the TTL.
+// A stale file is shown until the reload finishes.
+func (c *metadataCache) start() {
+	if c == nil {
+		return
+	}
+	if c.path != "" {
+		if saved, loaded, err := readMetadataFile(c.path); err == nil {
+			c.mu.Lock()
+			c.meta = saved.metadata()
+			c.columns = map[[2]string]bool{}
+			for _, key := range loaded {
+				c.columns[key] = true
+			}
+			c.mu.Unlock()
+			if time.Since(saved.Saved) < c.ttl {
+				return
+			}
+		}
+	}
+	c.refresh()
+}
+
+// hardRefresh forgets all metadata, including the saved file, and loads it
+// again
+func (c *metadataCache) hardRefresh() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	c.meta = &metadata{}
+	c.columns = nil
+	c.generation++
+	c.mu.Unlock()
+	if c.path != "" {
+		if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	c.refresh()
+	return nil
+}
+
+// refresh reloads the tables in the background. The current metadata stays
+// available until the new tables arrive, and the columns of the tables that
+// remain are kept until they are loaded again as they are needed.
+func (c *metadataCache) refresh() {
+	if c == nil || c.source == nil {
+		return
+	}
+	c.mu.Lock()
+	c.generation++
+	generation := c.generation
+	c.loading.Add(1)
+	c.mu.Unlock()
+
+	go func() {
+		defer c.loading.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		defer cancel()
+		m := c.source.fetchTables(ctx)
+
+		c.mu.Lock()
+		if generation != c.generation {
+			c.mu.Unlock()
+			return
+		}
+		c.meta = m.keepColumns(c.meta)
+		c.version++
+		c.generation++
+		c.columns = nil
+		c.mu.Unlock()
+		c.save()
+	}()
+}
+
+// loadColumns starts loading the columns of the schemas holding tables,
+// skipping schemas already loaded or being loaded
+func (c *metadataCache) loadColumns(tables []*tableInfo) {
+	if c == nil || c.source == nil || len(tables) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range tables {
+		key := [2]string{t.catalog, t.schema}
+		if _, ok := c.columns[key]; ok {
+			continue
+		}
+		if c.columns == nil {
+			c.columns = map[[2]string]bool{}
+		}
+		// Failed loads are not retried before the next refresh, rather
+		// than on every key press
+		c.columns[key] = false
+
+		generation := c.generation
+		c.loading.Add(1)
+		go func() {
+			defer c.loading.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+			defer cancel()
+			loaded := c.source.fetchColumns(ctx, key[0], key[1])
+
+			c.mu.Lock()
+			if generation != c.generation {
+				c.mu.Unlock()
+				return
+			}
+			c.meta = c.meta.withColumns(loaded)
+			c.columns[key] = true
+			c.version++
+			c.mu.Unlock()
+			c.save()
+		}()
+	}
+}
+
+// save writes the latest snapshot to the cache file. Errors are ignored,
+// the file only saves time on the next start.
+func (c *metadataCache) save() {
+	if c.path == "" {
+		return
+	}
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	c.mu.Lock()
+	m, version := c.meta, c.version
+	var loaded [][2]string
+	for key, done := range c.columns {
+		if done {
+			loaded = append(loaded, key)
+		}
+	}
+	c.mu.Unlock()
+
+	// A newer snapshot may have been saved by another load
+	if version <= c.savedVersion {
+		return
+	}
+	if err := writeMetadataFile(c.path, m, loaded); err == nil {
+		c.savedVersion = version
+	}
+}
+
+// wait blocks until all background loads have finished
+func (c *metadataCache) wait() {
+	if c != nil {
+		c.loading.Wait()
+	}
+}
+
+// keepColumns gives the tables of m, which is not yet published, the
+// columns they have in old
+func (m *metadata) keepColumns(old *metadata) *metadata {
+	columns := map[[3]string][]columnInfo{}
+	for _, t := range old.tables {
+		columns[[3]string{t.catalog, t.schema, t.name}] = t.columns
+	}
+	for _, t := range m.tables {
+		if t.columns == nil {
+			t.columns = columns[[3]string{t.catalog, t.schema, t.name}]
+		}
+	}
+	return m
+}
+
+// withColumns returns a copy of m with the columns of the tables in loaded
+func (m *metadata) withColumns(loaded *metadata) *metadata {
+	merged := &metadata{
+		catalogs:   slices.Clone(m.catalogs),
+		schemaList: slices.Clone(m.schemaList),
+		product:    m.product,
+		functions:  m.functions,
+	}
+	for _, t := range m.tables {
+		copied := merged.addTable(t.catalog, t.schema, t.name)
+		copied.kind = t.kind
+		copied.columns = t.columns
+	}
+	for _, t := range loaded.tables {
+		merged.addTable(t.catalog, t.schema, t.name).columns = t.columns
+	}
+	return merged
+}
@@ -0,0 +1,78 @@
+package prompt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/c-bata/go-prompt"
+)
+
+func suggestionTexts(suggestions []prompt.Suggest) []string {
+	texts := []string{}
+	for _, s := range suggestions {
+		texts = append(texts, s.Text)
+	}
+	return texts
+}
+
+func TestMetadataCache(t *testing.T) {
+	session, db, mock := newMockSession(t)
+	session.display.Quiet = true
+	session.catalog = newMetadataCache(&metadataSource{db: db})
+
+	// Tables are loaded in the background, without their columns
+	mock.ExpectQuery("INFORMATION_SCHEMA.SCHEMATA").WillReturnError(errors.New("no such table"))
+	mock.ExpectQuery("INFORMATION_SCHEMA.TABLES").WillReturnRows(
+		sqlmock.NewRows([]string{"TABLE_CATALOG", "TABLE_SCHEMA", "TABLE_NAME", "TABLE_TYPE"}).
+			AddRow(nil, "SALES", "ORDERS", "TABLE").
+			AddRow(nil, "HR", "EMPS", "TABLE"))
+	session.catalog.refresh()
+	session.catalog.wait()
+	if got := suggestionTexts(session.completer(completionDocument("SELECT * FROM SALES.", ""))); len(got) != 1 || got[0] != "ORDERS" {
+		t.Fatalf("Expected the loaded table ORDERS, got %v", got)
+	}
+
+	// Referencing a table loads the columns of its schema only
+	mock.ExpectQuery(`INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = \?`).WithArgs("SALES").WillReturnRows(
+		sqlmock.NewRows([]string{"TABLE_CATALOG", "TABLE_SCHEMA", "TABLE_NAME", "COLUMN_NAME", "DATA_TYPE", "IS_NULLABLE"}).
+			AddRow(nil, "SALES", "ORDERS", "ORDER_ID", "INTEGER", "NO"))
+	if got := suggestionTexts(session.completer(completionDocument("SELECT o.", " FROM SALES.ORDERS o"))); len(got) != 0 {
+		t.Errorf("Expected no columns before they are loaded, got %v", got)
+	}
+	session.catalog.wait()
+	for i := 0; i < 2; i++ {
+		// The second completion does not load the schema again
+		if got := suggestionTexts(session.completer(completionDocument("SELECT o.", " FROM SALES.ORDERS o"))); len(got) != 1 || got[0] != "ORDER_ID" {
+			t.Errorf("Expected column ORDER_ID once loaded, got %v", got)
+		}
+	}
+
+	// DDL refreshes the tables and drops the loaded columns
+	mock.ExpectQuery("CREATE TABLE SALES.UNITS").WillReturnRows(sqlmock.NewRows([]string{}))
+	mock.ExpectQuery("INFORMATION_SCHEMA.SCHEMATA").WillReturnError(errors.New("no such table"))
+	mock.ExpectQuery("INFORMATION_SCHEMA.TABLES").WillReturnRows(
+		sqlmock.NewRows([]string{"TABLE_CATALOG", "TABLE_SCHEMA", "TABLE_NAME", "TABLE_TYPE"}).
+			AddRow(nil, "SALES", "ORDERS", "TABLE").
+			AddRow(nil, "SALES", "UNITS", "TABLE"))
+	session.executor("CREATE TABLE SALES.UNITS (UNIT INTEGER);")
+	session.catalog.wait()
+	if got := suggestionTexts(session.completer(completionDocument("SELECT * FROM SALES.U", ""))); len(got) != 1 || got[0] != "UNITS" {
+		t.Errorf("Expected the new table UNITS after CREATE, got %v", got)
+	}
+
+	// \refresh reloads on demand
+	mock.ExpectQuery("INFORMATION_SCHEMA.SCHEMATA").WillReturnError(errors.New("no such table"))
+	mock.ExpectQuery("INFORMATION_SCHEMA.TABLES").WillReturnRows(
+		sqlmock.NewRows([]string{"TABLE_CATALOG", "TABLE_SCHEMA", "TABLE_NAME", "TABLE_TYPE"}).
+			AddRow(nil, "SALES", "ORDERS", "TABLE"))
+	session.runMetaCommand(`\refresh`)
+	session.catalog.wait()
+	if got := len(session.catalog.snapshot().tables); got != 1 {
+		t.Errorf("Expected 1 table after \\refresh, got %d", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
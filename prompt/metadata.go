@@ -0,0 +1,411 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+
+	"github.com/apache/calcite-avatica-go/v5/message"
+)
+
+// columnInfo is a column of a table with its SQL type
+type columnInfo struct {
+	name     string
+	typeName string
+	// nullable is YES, NO or empty when unknown, as in IS_NULLABLE
+	nullable string
+}
+
+// tableInfo is a table known to completion and \d
+type tableInfo struct {
+	catalog string
+	schema  string
+	name    string
+	// kind is the TABLE_TYPE reported by the server, such as TABLE, VIEW or
+	// SYSTEM TABLE
+	kind    string
+	columns []columnInfo
+}
+
+// schemaInfo is a schema and the catalog it belongs to
+type schemaInfo struct {
+	catalog string
+	name    string
+}
+
+// metadata holds the catalogs, schemas, tables and columns of the server
+type metadata struct {
+	catalogs   []string
+	schemaList []schemaInfo
+	tables     []*tableInfo
+	// product is the database product name reported by the server
+	product string
+	// functions are the functions the server reports beyond the standard
+	// ones, such as Phoenix user defined functions
+	functions []string
+	// index finds tables by catalog, schema and name while loading
+	index map[[3]string]*tableInfo
+}
+
+// addSchema records a schema, including one without any tables
+func (m *metadata) addSchema(catalog, name string) {
+	for _, s := range m.schemaList {
+		if s.catalog == catalog && s.name == name {
+			return
+		}
+	}
+	m.schemaList = append(m.schemaList, schemaInfo{catalog: catalog, name: name})
+}
+
+// addTable returns the table with the given name, adding it if needed
+func (m *metadata) addTable(catalog, schema, name string) *tableInfo {
+	key := [3]string{catalog, schema, name}
+	if t, ok := m.index[key]; ok {
+		return t
+	}
+	if m.index == nil {
+		m.index = map[[3]string]*tableInfo{}
+	}
+	t := &tableInfo{catalog: catalog, schema: schema, name: name}
+	m.tables = append(m.tables, t)
+	m.index[key] = t
+	return t
+}
+
+// lookupTable finds the tables a possibly schema- or catalog-qualified name
+// refers to. Without a schema, a name may match tables in several schemas.
+func (m *metadata) lookupTable(parts []string) []*tableInfo {
+	if m == nil || len(parts) == 0 {
+		return nil
+	}
+	name := parts[len(parts)-1]
+	schema, catalog := "", ""
+	if len(parts) > 1 {
+		schema = parts[len(parts)-2]
+	}
+	if len(parts) > 2 {
+		catalog = parts[len(parts)-3]
+	}
+	var found []*tableInfo
+	for _, t := range m.tables {
+		if strings.EqualFold(t.name, name) &&
+			(schema == "" || strings.EqualFold(t.schema, schema)) &&
+			(catalog == "" || strings.EqualFold(t.catalog, catalog)) {
+			found = append(found, t)
+		}
+	}
+	return found
+}
+
+// tablesIn returns the tables of a schema
+func (m *metadata) tablesIn(schema string) []*tableInfo {
+	if m == nil {
+		return nil
+	}
+	var found []*tableInfo
+	for _, t := range m.tables {
+		if strings.EqualFold(t.schema, schema) {
+			found = append(found, t)
+		}
+	}
+	return found
+}
+
+// schemas returns the names of all schemas, whether or not they hold tables
+func (m *metadata) schemas() []string {
+	if m == nil {
+		return nil
+	}
+	var names []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, s := range m.schemaList {
+		add(s.name)
+	}
+	for _, t := range m.tables {
+		add(t.schema)
+	}
+	return names
+}
+
+// metadataSource reads metadata from the server. The Avatica metadata RPCs
+// are used when available, since not every server has an
+// INFORMATION_SCHEMA; otherwise the INFORMATION_SCHEMA views are queried.
+// Missing metadata only makes completion less helpful, so errors are
+// ignored.
+//
+// The RPCs run in the background, so they use a connection of their own
+// rather than the one the session's statements and transactions run on,
+// and one request at a time.
+type metadataSource struct {
+	db  *sql.DB
+	rpc *avaticaClient
+
+	mu sync.Mutex
+	// connID is the Avatica connection of the RPCs, empty until opened
+	connID string
+}
+
+// connection returns the Avatica connection of the RPCs, opening it on first
+// use, or an empty string when they cannot be used. src.mu must be held.
+func (src *metadataSource) connection(ctx context.Context) string {
+	if src.rpc == nil || src.connID != "" {
+		return src.connID
+	}
+	if id, err := src.rpc.openConnection(ctx); err == nil {
+		src.connID = id
+	}
+	return src.connID
+}
+
+// close closes the Avatica connection of the RPCs, if it was opened
+func (src *metadataSource) close() {
+	if src == nil {
+		return
+	}
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	if src.connID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	_ = src.rpc.closeConnection(ctx, src.connID)
+	src.connID = ""
+}
+
+// fetchTables reads the catalogs, schemas and tables visible to the
+// connection, leaving columns to fetchColumns
+func (src *metadataSource) fetchTables(ctx context.Context) *metadata {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	var m *metadata
+	id := src.connection(ctx)
+	if id != "" {
+		if fetched, err := fetchAvaticaTables(ctx, src.rpc, id); err == nil {
+			m = fetched
+		}
+	}
+	if m == nil {
+		m = fetchInformationSchemaTables(ctx, src.db)
+	}
+	src.fetchFunctions(ctx, id, m)
+	return m
+}
+
+// fetchFunctions fills in the product name and the functions reported by
+// the server: the function lists of the database properties, read on the
+// Avatica connection if there is one, and the user defined functions of
+// Phoenix, which leaves those lists empty
+func (src *metadataSource) fetchFunctions(ctx context.Context, connectionID string, m *metadata) {
+	seen := map[string]bool{}
+	add := func(name string) {
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[strings.ToUpper(name)] {
+			seen[strings.ToUpper(name)] = true
+			m.functions = append(m.functions, name)
+		}
+	}
+
+	if connectionID != "" {
+		if props, err := src.rpc.databaseProperties(ctx, connectionID); err == nil {
+			m.product = props["GET_DATABASE_PRODUCT_NAME"]
+			for _, key := range []string{"GET_NUMERIC_FUNCTIONS", "GET_STRING_FUNCTIONS", "GET_SYSTEM_FUNCTIONS", "GET_TIME_DATE_FUNCTIONS"} {
+				for _, name := range strings.Split(props[key], ",") {
+					add(name)
+				}
+			}
+		}
+	}
+
+	if src.db == nil || !strings.Contains(strings.ToLower(m.product), "phoenix") {
+		return
+	}
+	rows, err := src.db.QueryContext(ctx, `SELECT DISTINCT FUNCTION_NAME FROM SYSTEM."FUNCTION"`)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name sql.NullString
+		if err := rows.Scan(&name); err == nil {
+			add(name.String)
+		}
+	}
+}
+
+// fetchColumns reads the columns of the tables in a schema, or of all
+// tables when schema is empty
+func (src *metadataSource) fetchColumns(ctx context.Context, catalog, schema string) *metadata {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	if id := src.connection(ctx); id != "" {
+		if m, err := fetchAvaticaColumns(ctx, src.rpc, id, catalog, schema); err == nil {
+			return m
+		}
+	}
+	return fetchInformationSchemaColumns(ctx, src.db, schema)
+}
+
+// fetchAvaticaTables loads metadata through getCatalogs, getSchemas and
+// getTables
+func fetchAvaticaTables(ctx context.Context, rpc *avaticaClient, connectionID string) (*metadata, error) {
+	m := &metadata{}
+
+	catalogs, err := rpc.resultSet(ctx, connectionID, message.CatalogsRequest_builder{
+		ConnectionId: connectionID,
+	}.Build())
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range catalogs {
+		m.catalogs = append(m.catalogs, row.String("TABLE_CAT"))
+	}
+
+	schemas, err := rpc.resultSet(ctx, connectionID, message.SchemasRequest_builder{
+		ConnectionId: connectionID,
+	}.Build())
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range schemas {
+		m.addSchema(row.String("TABLE_CATALOG"), row.String("TABLE_SCHEM"))
+	}
+
+	tables, err := rpc.resultSet(ctx, connectionID, message.TablesRequest_builder{
+		ConnectionId: connectionID,
+	}.Build())
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range tables {
+		t := m.addTable(row.String("TABLE_CAT"), row.String("TABLE_SCHEM"), row.String("TABLE_NAME"))
+		t.kind = row.String("TABLE_TYPE")
+	}
+	return m, nil
+}
+
+// fetchAvaticaColumns loads columns through getColumns
+func fetchAvaticaColumns(ctx context.Context, rpc *avaticaClient, connectionID, catalog, schema string) (*metadata, error) {
+	columns, err := rpc.resultSet(ctx, connectionID, message.ColumnsRequest_builder{
+		ConnectionId:     connectionID,
+		Catalog:          catalog,
+		HasCatalog:       catalog != "",
+		SchemaPattern:    schema,
+		HasSchemaPattern: schema != "",
+	}.Build())
+	if err != nil {
+		return nil, err
+	}
+
+	m := &metadata{}
+	for _, row := range columns {
+		// The schema is a LIKE pattern, so _ may have matched other schemas
+		if schema != "" && row.String("TABLE_SCHEM") != schema {
+			continue
+		}
+		t := m.addTable(row.String("TABLE_CAT"), row.String("TABLE_SCHEM"), row.String("TABLE_NAME"))
+		t.columns = append(t.columns, columnInfo{
+			name:     row.String("COLUMN_NAME"),
+			typeName: row.String("TYPE_NAME"),
+			nullable: row.String("IS_NULLABLE"),
+		})
+	}
+	return m, nil
+}
+
+// fetchInformationSchemaTables loads catalogs, schemas and tables from the
+// INFORMATION_SCHEMA views
+func fetchInformationSchemaTables(ctx context.Context, db *sql.DB) *metadata {
+	m := &metadata{}
+
+	// Fetch schemas, which some servers do not expose
+	schemaRows, err := db.QueryContext(ctx, "SELECT CATALOG_NAME, SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA")
+	if err == nil {
+		defer schemaRows.Close()
+		for schemaRows.Next() {
+			var catalog, schema sql.NullString
+			if err := schemaRows.Scan(&catalog, &schema); err == nil {
+				m.addSchema(catalog.String, schema.String)
+			}
+		}
+	}
+
+	// Fetch tables
+	tableRows, err := db.QueryContext(ctx, "SELECT TABLE_CATALOG, TABLE_SCHEMA, TABLE_NAME, TABLE_TYPE FROM INFORMATION_SCHEMA.TABLES")
+	if err == nil {
+		defer tableRows.Close()
+		for tableRows.Next() {
+			var catalog, schema, table, kind sql.NullString
+			if err := tableRows.Scan(&catalog, &schema, &table, &kind); err == nil {
+				m.addTable(catalog.String, schema.String, table.String).kind = kind.String
+			}
+		}
+	}
+
+	// Catalogs are only named by the rows above
+	seen := map[string]bool{}
+	for _, s := range m.schemaList {
+		if s.catalog != "" && !seen[s.catalog] {
+			seen[s.catalog] = true
+			m.catalogs = append(m.catalogs, s.catalog)
+		}
+	}
+	for _, t := range m.tables {
+		if t.catalog != "" && !seen[t.catalog] {
+			seen[t.catalog] = true
+			m.catalogs = append(m.catalogs, t.catalog)
+		}
+	}
+
+	return m
+}
+
+// fetchInformationSchemaColumns loads the columns of a schema, or of all
+// schemas when schema is empty, from INFORMATION_SCHEMA.COLUMNS
+func fetchInformationSchemaColumns(ctx context.Context, db *sql.DB, schema string) *metadata {
+	m := &metadata{}
+
+	query := "SELECT TABLE_CATALOG, TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME, DATA_TYPE, IS_NULLABLE FROM INFORMATION_SCHEMA.COLUMNS"
+	var args []interface{}
+	if schema != "" {
+		query += " WHERE TABLE_SCHEMA = ?"
+		args = append(args, schema)
+	}
+	columnRows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return m
+	}
+	defer columnRows.Close()
+	for columnRows.Next() {
+		var catalog, schema, table, column, typeName, nullable sql.NullString
+		if err := columnRows.Scan(&catalog, &schema, &table, &column, &typeName, &nullable); err == nil {
+			t := m.addTable(catalog.String, schema.String, table.String)
+			t.columns = append(t.columns, columnInfo{name: column.String, typeName: typeName.String, nullable: nullable.String})
+		}
+	}
+	return m
+}
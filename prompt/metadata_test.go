@@ -0,0 +1,89 @@
+package prompt
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// columnsNamed returns untyped columns with the given names
+func columnsNamed(names ...string) []columnInfo {
+	columns := make([]columnInfo, len(names))
+	for i, name := range names {
+		columns[i] = columnInfo{name: name}
+	}
+	return columns
+}
+
+func TestFetchMetadata(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Mock schemas query
+	schemaRows := sqlmock.NewRows([]string{"CATALOG_NAME", "SCHEMA_NAME"}).
+		AddRow(nil, "PUBLIC").
+		AddRow(nil, "EMPTY")
+	mock.ExpectQuery("SELECT CATALOG_NAME, SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA").WillReturnRows(schemaRows)
+
+	// Mock table names query
+	tableRows := sqlmock.NewRows([]string{"TABLE_CATALOG", "TABLE_SCHEMA", "TABLE_NAME", "TABLE_TYPE"}).
+		AddRow(nil, "PUBLIC", "USERS", "TABLE").
+		AddRow(nil, "SALES", "ORDERS", "TABLE").
+		AddRow(nil, "SALES", "USERS", "VIEW")
+	mock.ExpectQuery("SELECT TABLE_CATALOG, TABLE_SCHEMA, TABLE_NAME, TABLE_TYPE FROM INFORMATION_SCHEMA.TABLES").WillReturnRows(tableRows)
+
+	// Mock column names query
+	columnRows := sqlmock.NewRows([]string{"TABLE_CATALOG", "TABLE_SCHEMA", "TABLE_NAME", "COLUMN_NAME", "DATA_TYPE", "IS_NULLABLE"}).
+		AddRow(nil, "PUBLIC", "USERS", "ID", "INTEGER", "NO").
+		AddRow(nil, "PUBLIC", "USERS", "NAME", "VARCHAR", "YES").
+		AddRow(nil, "SALES", "ORDERS", "ID", "INTEGER", "NO").
+		AddRow(nil, "SALES", "USERS", "REGION", "VARCHAR", "YES")
+	mock.ExpectQuery("SELECT TABLE_CATALOG, TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME, DATA_TYPE, IS_NULLABLE FROM INFORMATION_SCHEMA.COLUMNS").WillReturnRows(columnRows)
+
+	source := &metadataSource{db: db}
+	m := source.fetchTables(context.Background())
+	m = m.withColumns(source.fetchColumns(context.Background(), "", ""))
+
+	if len(m.tables) != 3 {
+		t.Errorf("Expected 3 tables, got %d", len(m.tables))
+	}
+	if got := m.schemas(); !reflect.DeepEqual(got, []string{"PUBLIC", "EMPTY", "SALES"}) {
+		t.Errorf("Expected schemas PUBLIC, EMPTY and SALES, got %v", got)
+	}
+
+	tests := []struct {
+		parts []string
+		want  [][]string
+	}{
+		{[]string{"ORDERS"}, [][]string{{"ID"}}},
+		{[]string{"users"}, [][]string{{"ID", "NAME"}, {"REGION"}}},
+		{[]string{"sales", "USERS"}, [][]string{{"REGION"}}},
+		{[]string{"MISSING"}, nil},
+	}
+	for _, tt := range tests {
+		var got [][]string
+		for _, table := range m.lookupTable(tt.parts) {
+			var names []string
+			for _, c := range table.columns {
+				names = append(names, c.name)
+			}
+			got = append(got, names)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lookupTable(%v) columns = %v, want %v", tt.parts, got, tt.want)
+		}
+	}
+
+	if got := len(m.tablesIn("sales")); got != 2 {
+		t.Errorf("Expected 2 tables in SALES, got %d", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,186 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	calcitesql "github.com/satyakommula96/calcite-cli/calcitesql"
+)
+
+// openOutput opens path for query results, appending to it if requested
+func openOutput(path string, appendTo bool) (*os.File, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendTo {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	return os.OpenFile(path, flags, 0o644)
+}
+
+// setOutput sends the results of later queries to path, or back to the
+// terminal if path is empty. A path starting with >> is appended to.
+func (s *PromptSession) setOutput(path string) error {
+	appendTo := strings.HasPrefix(path, ">>")
+	if appendTo {
+		path = strings.TrimSpace(strings.TrimPrefix(path, ">>"))
+		if path == "" {
+			return errors.New("missing file name after >>")
+		}
+	}
+
+	var f *os.File
+	if path != "" {
+		var err error
+		if f, err = openOutput(path, appendTo); err != nil {
+			return err
+		}
+	}
+	s.closeOutput()
+	s.output = f
+	return nil
+}
+
+func (s *PromptSession) closeOutput() {
+	if s.output != nil {
+		if err := s.output.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error closing output file:", err)
+		}
+		s.output = nil
+	}
+}
+
+func (s *PromptSession) runOutput(args []string) {
+	if err := s.setOutput(strings.Join(args, " ")); err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening output file:", err)
+	}
+}
+
+// fileDisplay adapts the display settings for writing results to a file:
+// no terminal layout, colours or row limit, and the footer stays on the
+// terminal
+func fileDisplay(display calcitesql.Options, out io.Writer) calcitesql.Options {
+	display.Out = out
+	display.MaxRows = 0
+	display.Status = os.Stdout
+	display.TermWidth = 0
+	display.ColorNull = false
+	display.HeaderStyle = ""
+	return display
+}
+
+// copyCommand is a parsed \copy (query) TO 'file' [WITH] [options]
+type copyCommand struct {
+	query    string
+	path     string
+	appendTo bool
+	format   string
+	header   bool
+}
+
+// parseCopy parses the arguments of \copy. The query is found by matching
+// parentheses with the SQL lexer, so parentheses in strings do not count.
+func parseCopy(line string) (copyCommand, error) {
+	cmd := copyCommand{format: calcitesql.FormatCSV}
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "(") {
+		return cmd, errors.New("expected (query) TO 'file'")
+	}
+
+	end := -1
+	depth := 0
+	for _, tok := range calcitesql.SignificantTokens(line) {
+		if tok.Kind != calcitesql.TokenPunct {
+			continue
+		}
+		switch tok.Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth == 0 {
+			end = tok.Pos
+			break
+		}
+	}
+	if end < 0 {
+		return cmd, errors.New("missing closing parenthesis after the query")
+	}
+	cmd.query = strings.TrimSpace(line[1:end])
+	if cmd.query == "" {
+		return cmd, errors.New("missing query")
+	}
+
+	rest := calcitesql.SignificantTokens(line[end+1:])
+	if len(rest) < 2 || !rest[0].IsWord("TO") {
+		return cmd, errors.New("expected TO 'file' after the query")
+	}
+	if rest[1].Kind != calcitesql.TokenString || rest[1].Unterminated {
+		return cmd, fmt.Errorf("expected a quoted file name after TO, got %s", rest[1].Text)
+	}
+	cmd.path = strings.ReplaceAll(strings.Trim(rest[1].Text, "'"), "''", "'")
+
+	for _, tok := range rest[2:] {
+		word := strings.ToLower(tok.Text)
+		switch {
+		case tok.IsWord("WITH") || tok.Text == "(" || tok.Text == ")" || tok.Text == ",":
+		case tok.IsWord("HEADER"):
+			cmd.header = true
+		case tok.IsWord("APPEND"):
+			cmd.appendTo = true
+		case slices.Contains(calcitesql.Formats, word):
+			cmd.format = word
+		default:
+			return cmd, fmt.Errorf("unknown \\copy option %s", tok.Text)
+		}
+	}
+	return cmd, nil
+}
+
+// runCopy exports the result of a query to a file without changing where
+// other results go
+func (s *PromptSession) runCopy(line string) {
+	cmd, err := parseCopy(line)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error in \\copy:", err)
+		return
+	}
+	if !s.allowed(cmd.query) {
+		return
+	}
+
+	f, err := openOutput(cmd.path, cmd.appendTo)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening output file:", err)
+		return
+	}
+	display := fileDisplay(s.display, f)
+	display.Format = cmd.format
+	display.NoHeader = !cmd.header
+	display.Expanded = calcitesql.ExpandedOff
+	err = calcitesql.ExecuteQuery(s.queryer(), cmd.query, display)
+	if cerr := f.Close(); cerr != nil {
+		fmt.Fprintln(os.Stderr, "Error closing output file:", cerr)
+	}
+	s.checkConnection(err)
+}
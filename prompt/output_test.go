@@ -0,0 +1,116 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParseCopy(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    copyCommand
+		wantErr bool
+	}{
+		{
+			name: "csv header",
+			line: "(SELECT * FROM T) TO 'out.csv' WITH csv header",
+			want: copyCommand{query: "SELECT * FROM T", path: "out.csv", format: "csv", header: true},
+		},
+		{
+			name: "parentheses in query",
+			line: "(SELECT COUNT(*), ')' FROM T WHERE (A > 1)) TO 'o''k.json' json append",
+			want: copyCommand{query: "SELECT COUNT(*), ')' FROM T WHERE (A > 1)", path: "o'k.json", format: "json", appendTo: true},
+		},
+		{
+			name: "default format",
+			line: "(VALUES 1) TO '/tmp/x'",
+			want: copyCommand{query: "VALUES 1", path: "/tmp/x", format: "csv"},
+		},
+		{name: "no parenthesis", line: "SELECT 1 TO 'x'", wantErr: true},
+		{name: "unclosed", line: "(SELECT 1 TO 'x'", wantErr: true},
+		{name: "missing to", line: "(SELECT 1) 'x'", wantErr: true},
+		{name: "unquoted file", line: "(SELECT 1) TO x", wantErr: true},
+		{name: "unknown option", line: "(SELECT 1) TO 'x' WITH xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCopy(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCopy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseCopy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptSessionOutput(t *testing.T) {
+	session, _, mock := newMockSession(t)
+	session.display.Format = "csv"
+	session.display.MaxRows = 1
+
+	path := filepath.Join(t.TempDir(), "out.csv")
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"A"}).AddRow(1).AddRow(10))
+	mock.ExpectQuery("SELECT 2").WillReturnRows(sqlmock.NewRows([]string{"A"}).AddRow(2))
+	mock.ExpectQuery("SELECT 3").WillReturnRows(sqlmock.NewRows([]string{"A"}).AddRow(3))
+
+	session.executor(`\o ` + path)
+	session.executor("SELECT 1;")
+	session.executor(`\o >> ` + path)
+	session.executor("SELECT 2;")
+	session.executor(`\o`)
+	if session.output != nil {
+		t.Error("Expected \\o without a file to restore terminal output")
+	}
+	session.executor("SELECT 3;")
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reading output: %s", err)
+	}
+	// The footer stays on the terminal, the row limit only applies to the
+	// terminal and the third result was not redirected
+	if want := "A\n1\n10\nA\n2\n"; string(got) != want {
+		t.Errorf("Expected file content %q, got %q", want, got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPromptSessionCopy(t *testing.T) {
+	session, _, mock := newMockSession(t)
+	session.display.MaxRows = 1
+
+	path := filepath.Join(t.TempDir(), "export.csv")
+	rows := sqlmock.NewRows([]string{"ID", "NAME"}).AddRow(1, "a").AddRow(2, "b")
+	mock.ExpectQuery("^SELECT ID, NAME FROM T$").WillReturnRows(rows)
+	session.executor(`\copy (SELECT ID, NAME FROM T) TO '` + path + `' WITH csv header;`)
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reading export: %s", err)
+	}
+	// Exports ignore the interactive row limit
+	if want := "ID,NAME\n1,a\n2,b\n"; string(got) != want {
+		t.Errorf("Expected export %q, got %q", want, got)
+	}
+
+	session.runMetaCommand(`\safe on`)
+	session.executor(`\copy (DELETE FROM T) TO '` + path + `'`)
+	if got, _ := os.ReadFile(path); !strings.HasPrefix(string(got), "ID,NAME") {
+		t.Error("Expected safe mode to block the export before the file is touched")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
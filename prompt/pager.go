@@ -0,0 +1,156 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+
+	calcitesql "github.com/satyakommula96/calcite-cli/calcitesql"
+)
+
+// Pager modes accepted by \pager
+const (
+	pagerAuto = "auto"
+	pagerOn   = "on"
+	pagerOff  = "off"
+)
+
+var pagerModes = []string{pagerAuto, pagerOn, pagerOff}
+
+// defaultPager is used when $PAGER is not set. -S chops long lines instead of
+// wrapping tables, -R keeps colours, -F quits if the output fits on one screen
+// and -X leaves the output on the terminal afterwards.
+const defaultPager = "less -SRFX"
+
+// pagerCommand returns the pager to run, or nil if none is available
+func pagerCommand() []string {
+	command := os.Getenv("PAGER")
+	if command == "" {
+		command = defaultPager
+	}
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil
+	}
+	return args
+}
+
+// pagerWriter holds back output until it no longer fits on the screen and
+// then hands it over to the pager, so short results are printed directly.
+type pagerWriter struct {
+	args   []string
+	out    io.Writer
+	height int
+	buf    bytes.Buffer
+	lines  int
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	sigCh  chan os.Signal
+}
+
+// newPagerWriter returns the writer for query results in the given mode. The
+// result must be closed once the query output is complete.
+func newPagerWriter(mode string) io.WriteCloser {
+	w := &pagerWriter{out: os.Stdout}
+	if mode == pagerOff {
+		return w
+	}
+	_, height, ok := terminalSize()
+	if !ok {
+		// Never page into a pipe or file
+		return w
+	}
+	w.args = pagerCommand()
+	if mode == pagerAuto {
+		// Keep a line free for the prompt
+		w.height = height - 1
+	}
+	return w
+}
+
+func (w *pagerWriter) Write(p []byte) (int, error) {
+	if w.stdin != nil {
+		n, err := w.stdin.Write(p)
+		if err != nil {
+			// The user quit the pager before reading everything
+			return n, calcitesql.ErrOutputClosed
+		}
+		return n, nil
+	}
+	if w.args == nil {
+		return w.out.Write(p)
+	}
+	w.buf.Write(p)
+	w.lines += bytes.Count(p, []byte("\n"))
+	if w.lines < w.height {
+		return len(p), nil
+	}
+	if err := w.start(); err != nil {
+		// Fall back to printing directly if the pager cannot be started
+		w.args = nil
+		_, err = w.buf.WriteTo(w.out)
+		return len(p), err
+	}
+	if _, err := w.buf.WriteTo(w.stdin); err != nil {
+		return 0, calcitesql.ErrOutputClosed
+	}
+	return len(p), nil
+}
+
+// start runs the pager attached to the terminal
+func (w *pagerWriter) start() error {
+	cmd := exec.Command(w.args[0], w.args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	// Ctrl+C is meant for the pager; keep it from reaching the CLI while the
+	// pager runs so the prompt comes back cleanly
+	w.sigCh = make(chan os.Signal, 1)
+	signal.Notify(w.sigCh, os.Interrupt)
+	if err := cmd.Start(); err != nil {
+		signal.Stop(w.sigCh)
+		return err
+	}
+	w.cmd = cmd
+	w.stdin = stdin
+	return nil
+}
+
+func (w *pagerWriter) Close() error {
+	if w.cmd == nil {
+		_, err := w.buf.WriteTo(w.out)
+		return err
+	}
+	w.stdin.Close()
+	// The exit status of the pager is of no interest, quitting early with
+	// q or Ctrl+C is normal
+	_ = w.cmd.Wait()
+	signal.Stop(w.sigCh)
+	return nil
+}
@@ -0,0 +1,39 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPagerWriterShortOutput(t *testing.T) {
+	var out bytes.Buffer
+	w := &pagerWriter{args: []string{"pager-that-is-never-started"}, out: &out, height: 10}
+
+	w.Write([]byte("line 1\nline 2\n"))
+	if out.Len() != 0 {
+		t.Errorf("Expected output to be held back until it is complete, got %q", out.String())
+	}
+	w.Close()
+	if out.String() != "line 1\nline 2\n" {
+		t.Errorf("Expected short output to be printed directly, got %q", out.String())
+	}
+}
+
+func TestPagerWriterFallback(t *testing.T) {
+	var out bytes.Buffer
+	w := &pagerWriter{args: []string{"/nonexistent/pager"}, out: &out, height: 2}
+
+	w.Write([]byte("1\n2\n3\n"))
+	w.Write([]byte("4\n"))
+	w.Close()
+	if out.String() != "1\n2\n3\n4\n" {
+		t.Errorf("Expected output to be printed when the pager cannot start, got %q", out.String())
+	}
+}
+
+func TestPagerWriterOff(t *testing.T) {
+	w := newPagerWriter(pagerOff).(*pagerWriter)
+	if w.args != nil {
+		t.Errorf("Expected no pager when paging is off, got %v", w.args)
+	}
+}
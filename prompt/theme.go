@@ -0,0 +1,311 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+)
+
+// Built-in themes
+const (
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeMono         = "mono"
+)
+
+// ThemeNames lists the built-in themes
+var ThemeNames = []string{ThemeDark, ThemeLight, ThemeHighContrast, ThemeMono}
+
+// Theme holds the colours of the prompt, the input highlighting and the
+// query results
+type Theme struct {
+	prefix                prompt.Color
+	input                 prompt.Color
+	preview               prompt.Color
+	suggestion            prompt.Color
+	suggestionBG          prompt.Color
+	selectedSuggestion    prompt.Color
+	selectedSuggestionBG  prompt.Color
+	description           prompt.Color
+	descriptionBG         prompt.Color
+	selectedDescription   prompt.Color
+	selectedDescriptionBG prompt.Color
+	scrollbar             prompt.Color
+	scrollbarThumb        prompt.Color
+	highlight             highlightColors
+	// header and null are ANSI SGR parameter lists for result column names
+	// and NULL, empty for plain text
+	header string
+	null   string
+}
+
+var themes = map[string]Theme{
+	ThemeDark: {
+		prefix:                prompt.Yellow,
+		input:                 prompt.Fuchsia,
+		preview:               prompt.Turquoise,
+		suggestion:            prompt.White,
+		suggestionBG:          prompt.DarkGray,
+		selectedSuggestion:    prompt.Black,
+		selectedSuggestionBG:  prompt.Turquoise,
+		description:           prompt.LightGray,
+		descriptionBG:         prompt.DarkGray,
+		selectedDescription:   prompt.Black,
+		selectedDescriptionBG: prompt.Turquoise,
+		scrollbar:             prompt.DarkGray,
+		scrollbarThumb:        prompt.LightGray,
+		highlight: highlightColors{
+			keyword:    prompt.Turquoise,
+			identifier: prompt.White,
+			str:        prompt.Green,
+			number:     prompt.Fuchsia,
+			comment:    prompt.DarkGray,
+			operator:   prompt.DefaultColor,
+			unmatched:  prompt.Red,
+		},
+		header: "1;36",
+		null:   "2",
+	},
+	ThemeLight: {
+		prefix:                prompt.DarkBlue,
+		input:                 prompt.Purple,
+		preview:               prompt.DarkBlue,
+		suggestion:            prompt.Black,
+		suggestionBG:          prompt.LightGray,
+		selectedSuggestion:    prompt.White,
+		selectedSuggestionBG:  prompt.DarkBlue,
+		description:           prompt.DarkGray,
+		descriptionBG:         prompt.LightGray,
+		selectedDescription:   prompt.White,
+		selectedDescriptionBG: prompt.DarkBlue,
+		scrollbar:             prompt.LightGray,
+		scrollbarThumb:        prompt.DarkGray,
+		highlight: highlightColors{
+			keyword:    prompt.DarkBlue,
+			identifier: prompt.Black,
+			str:        prompt.DarkGreen,
+			number:     prompt.Purple,
+			comment:    prompt.DarkGray,
+			operator:   prompt.DefaultColor,
+			unmatched:  prompt.DarkRed,
+		},
+		header: "1;34",
+		null:   "2",
+	},
+	ThemeHighContrast: {
+		prefix:                prompt.White,
+		input:                 prompt.White,
+		preview:               prompt.Yellow,
+		suggestion:            prompt.White,
+		suggestionBG:          prompt.Black,
+		selectedSuggestion:    prompt.Black,
+		selectedSuggestionBG:  prompt.Yellow,
+		description:           prompt.White,
+		descriptionBG:         prompt.Black,
+		selectedDescription:   prompt.Black,
+		selectedDescriptionBG: prompt.Yellow,
+		scrollbar:             prompt.Black,
+		scrollbarThumb:        prompt.White,
+		highlight: highlightColors{
+			keyword:    prompt.Yellow,
+			identifier: prompt.White,
+			str:        prompt.Green,
+			number:     prompt.Turquoise,
+			comment:    prompt.LightGray,
+			operator:   prompt.White,
+			unmatched:  prompt.Red,
+		},
+		header: "1;4",
+		null:   "7",
+	},
+	ThemeMono: {
+		prefix:                prompt.DefaultColor,
+		input:                 prompt.DefaultColor,
+		preview:               prompt.DefaultColor,
+		suggestion:            prompt.DefaultColor,
+		suggestionBG:          prompt.DefaultColor,
+		selectedSuggestion:    prompt.DefaultColor,
+		selectedSuggestionBG:  prompt.DefaultColor,
+		description:           prompt.DefaultColor,
+		descriptionBG:         prompt.DefaultColor,
+		selectedDescription:   prompt.DefaultColor,
+		selectedDescriptionBG: prompt.DefaultColor,
+		scrollbar:             prompt.DefaultColor,
+		scrollbarThumb:        prompt.DefaultColor,
+		highlight: highlightColors{
+			keyword:    prompt.DefaultColor,
+			identifier: prompt.DefaultColor,
+			str:        prompt.DefaultColor,
+			number:     prompt.DefaultColor,
+			comment:    prompt.DefaultColor,
+			operator:   prompt.DefaultColor,
+			unmatched:  prompt.DefaultColor,
+		},
+	},
+}
+
+// colorNames maps the names used in the configuration file to colours
+var colorNames = map[string]prompt.Color{
+	"default":    prompt.DefaultColor,
+	"black":      prompt.Black,
+	"dark_red":   prompt.DarkRed,
+	"dark_green": prompt.DarkGreen,
+	"brown":      prompt.Brown,
+	"dark_blue":  prompt.DarkBlue,
+	"purple":     prompt.Purple,
+	"cyan":       prompt.Cyan,
+	"light_gray": prompt.LightGray,
+	"dark_gray":  prompt.DarkGray,
+	"red":        prompt.Red,
+	"green":      prompt.Green,
+	"yellow":     prompt.Yellow,
+	"blue":       prompt.Blue,
+	"fuchsia":    prompt.Fuchsia,
+	"turquoise":  prompt.Turquoise,
+	"white":      prompt.White,
+}
+
+// themeColors maps the keys of a user theme to the colour they set
+var themeColors = map[string]func(t *Theme) *prompt.Color{
+	"prefix":                  func(t *Theme) *prompt.Color { return &t.prefix },
+	"input":                   func(t *Theme) *prompt.Color { return &t.input },
+	"preview":                 func(t *Theme) *prompt.Color { return &t.preview },
+	"suggestion":              func(t *Theme) *prompt.Color { return &t.suggestion },
+	"suggestion_bg":           func(t *Theme) *prompt.Color { return &t.suggestionBG },
+	"selected_suggestion":     func(t *Theme) *prompt.Color { return &t.selectedSuggestion },
+	"selected_suggestion_bg":  func(t *Theme) *prompt.Color { return &t.selectedSuggestionBG },
+	"description":             func(t *Theme) *prompt.Color { return &t.description },
+	"description_bg":          func(t *Theme) *prompt.Color { return &t.descriptionBG },
+	"selected_description":    func(t *Theme) *prompt.Color { return &t.selectedDescription },
+	"selected_description_bg": func(t *Theme) *prompt.Color { return &t.selectedDescriptionBG },
+	"scrollbar":               func(t *Theme) *prompt.Color { return &t.scrollbar },
+	"scrollbar_thumb":         func(t *Theme) *prompt.Color { return &t.scrollbarThumb },
+	"keyword":                 func(t *Theme) *prompt.Color { return &t.highlight.keyword },
+	"identifier":              func(t *Theme) *prompt.Color { return &t.highlight.identifier },
+	"string":                  func(t *Theme) *prompt.Color { return &t.highlight.str },
+	"number":                  func(t *Theme) *prompt.Color { return &t.highlight.number },
+	"comment":                 func(t *Theme) *prompt.Color { return &t.highlight.comment },
+	"operator":                func(t *Theme) *prompt.Color { return &t.highlight.operator },
+	"unmatched":               func(t *Theme) *prompt.Color { return &t.highlight.unmatched },
+}
+
+// ResolveTheme returns the built-in or user-defined theme called name. A
+// user theme starts from the built-in theme named by its "base" key, dark
+// by default, and overrides colours by key: prompt and highlighting
+// colours take colour names such as dark_blue, while "header" and "null"
+// take ANSI SGR parameters such as "1;36".
+func ResolveTheme(name string, userThemes map[string]map[string]string) (Theme, error) {
+	settings, ok := userThemes[name]
+	if !ok {
+		if t, ok := themes[name]; ok {
+			return t, nil
+		}
+		names := slices.Clone(ThemeNames)
+		for user := range userThemes {
+			names = append(names, user)
+		}
+		return Theme{}, fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+
+	base := ThemeDark
+	if b, ok := settings["base"]; ok {
+		base = b
+	}
+	t, ok := themes[base]
+	if !ok {
+		return Theme{}, fmt.Errorf("theme %q: unknown base theme %q, expected one of %s", name, base, strings.Join(ThemeNames, ", "))
+	}
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := settings[key]
+		switch key {
+		case "base":
+		case "header", "null":
+			if strings.Trim(value, "0123456789;") != "" {
+				return Theme{}, fmt.Errorf("theme %q: %s must be ANSI SGR parameters such as 1;36, got %q", name, key, value)
+			}
+			if key == "header" {
+				t.header = value
+			} else {
+				t.null = value
+			}
+		default:
+			color, ok := themeColors[key]
+			if !ok {
+				return Theme{}, fmt.Errorf("theme %q: unknown key %q", name, key)
+			}
+			c, ok := colorNames[strings.ToLower(value)]
+			if !ok {
+				return Theme{}, fmt.Errorf("theme %q: unknown colour %q for %s", name, value, key)
+			}
+			*color(&t) = c
+		}
+	}
+	return t, nil
+}
+
+// monochrome reports whether output should have no colours: when NO_COLOR
+// is set, see https://no-color.org, or stdout is not a terminal
+func monochrome() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return true
+	}
+	_, _, ok := terminalSize()
+	return !ok
+}
+
+// promptOptions returns the go-prompt options that apply the theme
+func (t Theme) promptOptions() []prompt.Option {
+	return []prompt.Option{
+		prompt.OptionPrefixTextColor(t.prefix),
+		prompt.OptionInputTextColor(t.input),
+		prompt.OptionPreviewSuggestionTextColor(t.preview),
+		prompt.OptionSuggestionTextColor(t.suggestion),
+		prompt.OptionSuggestionBGColor(t.suggestionBG),
+		prompt.OptionSelectedSuggestionTextColor(t.selectedSuggestion),
+		prompt.OptionSelectedSuggestionBGColor(t.selectedSuggestionBG),
+		prompt.OptionDescriptionTextColor(t.description),
+		prompt.OptionDescriptionBGColor(t.descriptionBG),
+		prompt.OptionSelectedDescriptionTextColor(t.selectedDescription),
+		prompt.OptionSelectedDescriptionBGColor(t.selectedDescriptionBG),
+		prompt.OptionScrollbarBGColor(t.scrollbar),
+		prompt.OptionScrollbarThumbColor(t.scrollbarThumb),
+	}
+}
+
+// applyTheme styles the results of the session with the theme. Without a
+// NULL style NULL is not coloured at all.
+func (s *PromptSession) applyTheme(t Theme) {
+	s.display.HeaderStyle = t.header
+	s.display.NullStyle = t.null
+	if t.null == "" {
+		s.display.ColorNull = false
+	}
+}
@@ -0,0 +1,70 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/satyakommula96/calcite-cli/calcitesql"
+)
+
+func TestResolveTheme(t *testing.T) {
+	for _, name := range ThemeNames {
+		if _, err := ResolveTheme(name, nil); err != nil {
+			t.Errorf("ResolveTheme(%q) failed: %s", name, err)
+		}
+	}
+
+	user := map[string]map[string]string{
+		"mine":    {"base": "light", "keyword": "Dark_Red", "header": "1;35"},
+		"plain":   {"null": ""},
+		"badkey":  {"keywords": "red"},
+		"badcol":  {"keyword": "crimson"},
+		"badbase": {"base": "solarized"},
+		"badsgr":  {"header": "\x1b[1m"},
+	}
+	mine, err := ResolveTheme("mine", user)
+	if err != nil {
+		t.Fatalf("Unexpected error resolving theme: %s", err)
+	}
+	light := themes[ThemeLight]
+	if mine.highlight.keyword != prompt.DarkRed || mine.header != "1;35" {
+		t.Errorf("Expected the overrides, got keyword %v and header %q", mine.highlight.keyword, mine.header)
+	}
+	if mine.prefix != light.prefix || mine.null != light.null {
+		t.Error("Expected the other colours of the light theme")
+	}
+
+	plain, err := ResolveTheme("plain", user)
+	if err != nil {
+		t.Fatalf("Unexpected error resolving theme: %s", err)
+	}
+	if plain.prefix != themes[ThemeDark].prefix || plain.null != "" {
+		t.Errorf("Expected the dark theme without a NULL style, got %+v", plain)
+	}
+
+	for _, name := range []string{"badkey", "badcol", "badbase", "badsgr", "missing"} {
+		if _, err := ResolveTheme(name, user); err == nil {
+			t.Errorf("Expected an error for theme %q", name)
+		}
+	}
+}
+
+func TestMonochrome(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if !monochrome() {
+		t.Error("Expected monochrome output with NO_COLOR set")
+	}
+}
+
+func TestApplyTheme(t *testing.T) {
+	session := &PromptSession{display: calcitesql.Options{ColorNull: true}}
+	session.applyTheme(themes[ThemeDark])
+	if session.display.HeaderStyle != "1;36" || session.display.NullStyle != "2" || !session.display.ColorNull {
+		t.Errorf("Unexpected dark display %+v", session.display)
+	}
+
+	session.applyTheme(themes[ThemeMono])
+	if session.display.HeaderStyle != "" || session.display.ColorNull {
+		t.Errorf("Expected plain results for mono, got %+v", session.display)
+	}
+}
@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to you under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prompt
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	calcitesql "github.com/satyakommula96/calcite-cli/calcitesql"
+)
+
+// Transaction control statements intercepted by the prompt
+const (
+	txBegin    = "BEGIN"
+	txCommit   = "COMMIT"
+	txRollback = "ROLLBACK"
+)
+
+// IsolationLevels are the names of the isolation levels accepted by
+// --isolation and \isolation. "default" keeps the level of the server.
+var IsolationLevels = []string{"default", "read-uncommitted", "read-committed", "repeatable-read", "serializable"}
+
+// isolationLevels maps the names onto the levels supported by avatica-go
+var isolationLevels = map[string]sql.IsolationLevel{
+	"default":          sql.LevelDefault,
+	"read-uncommitted": sql.LevelReadUncommitted,
+	"read-committed":   sql.LevelReadCommitted,
+	"repeatable-read":  sql.LevelRepeatableRead,
+	"serializable":     sql.LevelSerializable,
+}
+
+// jdbcIsolation maps the levels onto the JDBC constants Avatica expects
+var jdbcIsolation = map[sql.IsolationLevel]uint32{
+	sql.LevelReadUncommitted: 1,
+	sql.LevelReadCommitted:   2,
+	sql.LevelRepeatableRead:  4,
+	sql.LevelSerializable:    8,
+}
+
+// transactionCommand reports which transaction control statement the query is,
+// or an empty string if it should be sent to the server as is.
+func transactionCommand(query string) string {
+	words := strings.Fields(strings.ToUpper(strings.TrimRight(strings.TrimSpace(query), "; \t\n")))
+	switch strings.Join(words, " ") {
+	case "BEGIN", "BEGIN WORK", "BEGIN TRANSACTION", "START TRANSACTION":
+		return txBegin
+	case "COMMIT", "COMMIT WORK":
+		return txCommit
+	case "ROLLBACK", "ROLLBACK WORK":
+		return txRollback
+	}
+	return ""
+}
+
+// handleTransaction maps a transaction control statement onto the session's *sql.Tx
+func (s *PromptSession) handleTransaction(cmd string) {
+	switch cmd {
+	case txBegin:
+		if s.tx != nil {
+			fmt.Fprintln(os.Stderr, "WARNING: there is already a transaction in progress")
+			return
+		}
+		tx, err := s.conn.BeginTx(context.Background(), &sql.TxOptions{Isolation: s.isolation})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error starting transaction:", err)
+			return
+		}
+		s.tx = tx
+		fmt.Println("BEGIN")
+	case txCommit:
+		if s.tx == nil {
+			fmt.Fprintln(os.Stderr, "WARNING: there is no transaction in progress")
+			return
+		}
+		err := s.tx.Commit()
+		s.tx = nil
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error committing transaction:", err)
+			return
+		}
+		fmt.Println("COMMIT")
+	case txRollback:
+		if s.tx == nil {
+			fmt.Fprintln(os.Stderr, "WARNING: there is no transaction in progress")
+			return
+		}
+		err := s.tx.Rollback()
+		s.tx = nil
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error rolling back transaction:", err)
+			return
+		}
+		fmt.Println("ROLLBACK")
+	}
+}
+
+// queryer returns the open transaction if there is one, otherwise the pinned connection
+func (s *PromptSession) queryer() calcitesql.Queryer {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.conn
+}
+
+// discardTransaction rolls back any uncommitted work before the prompt exits
+func (s *PromptSession) discardTransaction() {
+	if s.tx == nil {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "WARNING: rolling back uncommitted transaction")
+	if err := s.tx.Rollback(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error rolling back transaction:", err)
+	}
+	s.tx = nil
+}
+
+// runIsolation shows or changes the isolation level of the pinned
+// connection, which BEGIN also uses
+func (s *PromptSession) runIsolation(args []string) {
+	if len(args) == 0 {
+		for name, level := range isolationLevels {
+			if level == s.isolation {
+				fmt.Println("Transaction isolation:", name)
+			}
+		}
+		return
+	}
+	level, ok := isolationLevels[strings.ToLower(args[0])]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Invalid isolation level %s, expected one of %s\n", args[0], strings.Join(IsolationLevels, ", "))
+		return
+	}
+	if s.tx != nil {
+		fmt.Fprintln(os.Stderr, "Error: the isolation level cannot change inside a transaction")
+		return
+	}
+	previous := s.isolation
+	s.isolation = level
+	if s.conn == nil {
+		return
+	}
+	if err := s.syncConnection(); err != nil {
+		fmt.Fprintln(os.Stderr, "WARNING: the isolation level only applies to BEGIN:", err)
+		return
+	}
+	if level == sql.LevelDefault && previous != sql.LevelDefault {
+		fmt.Println("The connection keeps its current isolation level until \\reconnect")
+	}
+}
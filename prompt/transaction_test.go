@@ -0,0 +1,87 @@
+package prompt
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTransactionCommand(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "BEGIN;", want: txBegin},
+		{query: "begin transaction ;", want: txBegin},
+		{query: "START   TRANSACTION;", want: txBegin},
+		{query: "commit;", want: txCommit},
+		{query: "COMMIT WORK;", want: txCommit},
+		{query: "rollback;", want: txRollback},
+		{query: "SELECT * FROM BEGIN;", want: ""},
+		{query: "UPSERT INTO T VALUES (1);", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := transactionCommand(tt.query); got != tt.want {
+			t.Errorf("transactionCommand(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestPromptSessionTransaction(t *testing.T) {
+	session, _, mock := newMockSession(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPSERT INTO T VALUES \\(1\\)").WillReturnRows(sqlmock.NewRows([]string{"ROWS"}))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	session.executor("BEGIN;")
+	if session.tx == nil {
+		t.Fatal("Expected a transaction to be open after BEGIN")
+	}
+	if prefix, _ := session.LivePrefix(); prefix != "calcite \U0001F48E:sql(tx)> " {
+		t.Errorf("Expected transaction prefix, got %q", prefix)
+	}
+
+	session.executor("UPSERT INTO T VALUES (1);")
+	session.executor("COMMIT;")
+	if session.tx != nil {
+		t.Error("Expected no transaction after COMMIT")
+	}
+
+	session.executor("START TRANSACTION;")
+	session.executor("exit")
+	if !session.exitWarned {
+		t.Error("Expected exit to be refused while a transaction is open")
+	}
+	session.executor("ROLLBACK;")
+	if session.tx != nil || session.exitWarned {
+		t.Error("Expected rollback to end the transaction and reset the exit warning")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRunIsolation(t *testing.T) {
+	session := &PromptSession{}
+
+	session.runMetaCommand(`\isolation SERIALIZABLE`)
+	if session.isolation != sql.LevelSerializable {
+		t.Errorf("Expected serializable isolation, got %v", session.isolation)
+	}
+
+	session.runMetaCommand(`\isolation snapshot`)
+	if session.isolation != sql.LevelSerializable {
+		t.Errorf("Expected an unsupported level to be rejected, got %v", session.isolation)
+	}
+
+	session.runMetaCommand(`\isolation default`)
+	if session.isolation != sql.LevelDefault {
+		t.Errorf("Expected default isolation, got %v", session.isolation)
+	}
+}